@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -24,19 +25,30 @@ func main() {
 
 func setupTest() *config.Config {
 	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	if apiKey == "" {
-		fmt.Println("OPENROUTER_API_KEY not set, cannot run integration test")
+	// AXON_FIXTURE replays a scripted session instead of calling OpenRouter
+	fixture := os.Getenv("AXON_FIXTURE")
+	if apiKey == "" && fixture == "" {
+		fmt.Println("OPENROUTER_API_KEY or AXON_FIXTURE not set, cannot run integration test")
 		os.Exit(1)
 	}
 
 	fmt.Println("🎮 Starting Axon Game Integration Test...")
 
+	aiConfig := config.AIConfig{
+		OpenRouterAPIKey: apiKey,
+		GeminiAPIKey:     "",
+		DefaultModel:     "openai/gpt-4o-mini",
+	}
+	if fixture != "" {
+		fmt.Printf("   Replaying scripted responses from %s\n", fixture)
+		aiConfig.DefaultProvider = "scripted"
+		aiConfig.Providers = map[string]config.ProviderConfig{
+			"scripted": {Type: "scripted", Fixture: fixture},
+		}
+	}
+
 	return &config.Config{
-		AI: config.AIConfig{
-			OpenRouterAPIKey: apiKey,
-			GeminiAPIKey:     "",
-			DefaultModel:     "openai/gpt-4o-mini",
-		},
+		AI: aiConfig,
 		Game: config.GameConfig{
 			HistoryLimit: 1000,
 			SaveDir:      "/tmp/axon_test_saves",
@@ -58,7 +70,7 @@ func createGameEngine(cfg *config.Config) (*game.Engine, *game.GameState) {
 func testWorldGeneration(engine *game.Engine, state *game.GameState) {
 	fmt.Println("\n📡 Testing world generation...")
 
-	errorMessage := engine.InitializeWorld(state, "A mysterious forest clearing with ancient ruins")
+	errorMessage := engine.InitializeWorld(context.Background(), state, "A mysterious forest clearing with ancient ruins")
 	if errorMessage != nil {
 		fmt.Printf("❌ World generation failed: %v\n", errorMessage)
 		os.Exit(1)
@@ -94,7 +106,7 @@ func testPlayerActions(engine *game.Engine, state *game.GameState) {
 		fmt.Printf("   > %s\n", action)
 		prevHistoryCount := len(state.History)
 
-		err := engine.ProcessPlayerAction(state, action)
+		err := engine.ProcessPlayerAction(context.Background(), state, action)
 		if err != nil {
 			fmt.Printf("❌ Action failed: %v\n", err)
 			continue
@@ -115,7 +127,7 @@ func testPlayerActions(engine *game.Engine, state *game.GameState) {
 func testActionSuggestions(engine *game.Engine, state *game.GameState) {
 	fmt.Println("💡 Testing action suggestions...")
 
-	suggestions, err := engine.GenerateActionSuggestions(state)
+	suggestions, err := engine.GenerateActionSuggestions(context.Background(), state)
 	if err != nil {
 		fmt.Printf("❌ Action suggestions failed: %v\n", err)
 	} else {
@@ -134,7 +146,7 @@ func testSystemCommands(engine *game.Engine, state *game.GameState) {
 		fmt.Printf("   > %s\n", cmd)
 		prevHistoryCount := len(state.History)
 
-		err := engine.ProcessPlayerAction(state, cmd)
+		err := engine.ProcessPlayerAction(context.Background(), state, cmd)
 		if err != nil {
 			fmt.Printf("❌ Command failed: %v\n", err)
 			continue
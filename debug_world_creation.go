@@ -4,6 +4,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -41,11 +42,9 @@ func main() {
 		Context:   []string{"You are a helpful assistant."},
 	}
 
-	resp, err := client.Generate(req)
+	resp, err := client.Generate(context.Background(), req)
 	if err != nil {
 		fmt.Printf("Simple AI request failed: %v\n", err)
-	} else if resp.Error != nil {
-		fmt.Printf("Simple AI request error: %v\n", resp.Error)
 	} else {
 		fmt.Printf("Simple AI request successful: %s\n", resp.Text)
 	}
@@ -55,7 +54,7 @@ func main() {
 	engine := game.NewEngine(cfg)
 	gameState := game.NewGameState()
 
-	err = engine.InitializeWorld(gameState, "A cyberpunk city in 2077")
+	err = engine.InitializeWorld(context.Background(), gameState, "A cyberpunk city in 2077")
 	if err != nil {
 		fmt.Printf("World creation failed: %v\n", err)
 	} else {
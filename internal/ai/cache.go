@@ -0,0 +1,309 @@
+package ai
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"axon/internal/config"
+	"axon/internal/logger"
+)
+
+const (
+	// Number of responses kept in memory when the configuration sets no limit
+	defaultCacheEntries = 256
+	// Number of responses kept on disk when the configuration sets no limit
+	defaultCacheDiskEntries = 4096
+)
+
+// cacheEntry is a cached completion as stored in memory and on disk
+type cacheEntry struct {
+	Key       string    `json:"key"`
+	Text      string    `json:"text"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// responseCache is a content-addressed cache of completions: an in-memory
+// LRU backed by one JSON file per response on disk
+type responseCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+	maxEntries int
+	// dir holds the on-disk copies; empty keeps the cache in memory only
+	dir string
+	// diskEntries counts the files in dir, pruned back once it passes maxDiskEntries
+	diskEntries    int
+	maxDiskEntries int
+	// ttl is how long a response stays valid; zero never expires
+	ttl time.Duration
+	now func() time.Time
+}
+
+// newResponseCache creates a cache from configuration, or returns nil when caching is disabled
+func newResponseCache(cfg config.CacheConfig) *responseCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	maxDiskEntries := cfg.MaxDiskEntries
+	if maxDiskEntries <= 0 {
+		maxDiskEntries = defaultCacheDiskEntries
+	}
+	c := &responseCache{
+		entries:        make(map[string]*list.Element),
+		order:          list.New(),
+		maxEntries:     maxEntries,
+		dir:            cfg.Dir,
+		maxDiskEntries: maxDiskEntries,
+		ttl:            time.Duration(cfg.TTLSeconds) * time.Second,
+		now:            time.Now,
+	}
+	c.pruneDisk()
+	return c
+}
+
+// cacheKey hashes everything that shapes a completion: the model, context,
+// prompt and generation settings. The task only selects settings, so it is left out.
+func cacheKey(req Request) string {
+	data, _ := json.Marshal(struct {
+		Model       string   `json:"model"`
+		Context     []string `json:"context"`
+		Prompt      string   `json:"prompt"`
+		MaxTokens   int      `json:"max_tokens"`
+		JSON        bool     `json:"json"`
+		Temperature *float64 `json:"temperature"`
+		TopP        *float64 `json:"top_p"`
+	}{req.Model, req.Context, req.Prompt, req.MaxTokens, req.JSON, req.Temperature, req.TopP})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the cached response for a key, checking memory before disk
+func (c *responseCache) get(key string) (*Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		if c.expired(entry) {
+			c.remove(element)
+			return nil, false
+		}
+		c.order.MoveToFront(element)
+		return cachedResponse(entry), true
+	}
+
+	entry, ok := c.readDisk(key)
+	if !ok {
+		return nil, false
+	}
+	if c.expired(entry) {
+		c.removeDisk(key)
+		return nil, false
+	}
+	c.store(entry)
+	return cachedResponse(entry), true
+}
+
+// put caches a successful response under a key
+func (c *responseCache) put(key string, resp *Response) {
+	entry := &cacheEntry{Key: key, Text: resp.Text, Model: resp.Model, CreatedAt: c.now()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	c.store(entry)
+	c.writeDisk(entry)
+}
+
+// cachedResponse builds the response for a cache hit. It carries no usage
+// since nothing was paid for it.
+func cachedResponse(entry *cacheEntry) *Response {
+	return &Response{Text: entry.Text, Model: entry.Model, Cached: true}
+}
+
+// expired reports whether an entry has outlived the cache's TTL
+func (c *responseCache) expired(entry *cacheEntry) bool {
+	return c.ttl > 0 && c.now().Sub(entry.CreatedAt) > c.ttl
+}
+
+// store adds an entry to memory, evicting the least recently used past the limit
+func (c *responseCache) store(entry *cacheEntry) {
+	c.entries[entry.Key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).Key)
+	}
+}
+
+// remove drops an entry from memory and disk
+func (c *responseCache) remove(element *list.Element) {
+	key := element.Value.(*cacheEntry).Key
+	c.order.Remove(element)
+	delete(c.entries, key)
+	c.removeDisk(key)
+}
+
+// path returns the file an entry is stored in on disk
+func (c *responseCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// readDisk loads an entry from disk; missing or unreadable files are misses
+func (c *responseCache) readDisk(key string) (*cacheEntry, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		logger.Error("Ignoring corrupt cache file %s: %v", c.path(key), err)
+		return nil, false
+	}
+	return &entry, true
+}
+
+// writeDisk saves an entry to disk, logging rather than failing on error
+func (c *responseCache) writeDisk(entry *cacheEntry) {
+	if c.dir == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logger.Error("Failed to encode cache entry: %v", err)
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		logger.Error("Failed to create cache directory: %v", err)
+		return
+	}
+	path := c.path(entry.Key)
+	_, statErr := os.Stat(path)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		logger.Error("Failed to write cache entry: %v", err)
+		return
+	}
+	// The modification time records when the entry was created, so pruning
+	// can age files without decoding them
+	if err := os.Chtimes(path, entry.CreatedAt, entry.CreatedAt); err != nil {
+		logger.Error("Failed to date cache entry: %v", err)
+	}
+	if os.IsNotExist(statErr) {
+		c.diskEntries++
+	}
+	if c.diskEntries > c.maxDiskEntries {
+		c.pruneDisk()
+	}
+}
+
+// removeDisk deletes an entry's file if there is one
+func (c *responseCache) removeDisk(key string) {
+	if c.dir == "" {
+		return
+	}
+	err := os.Remove(c.path(key))
+	switch {
+	case err == nil:
+		c.diskEntries--
+	case !os.IsNotExist(err):
+		logger.Error("Failed to remove cache entry: %v", err)
+	}
+}
+
+// pruneDisk deletes expired files and, past the disk limit, the oldest ones.
+// It trims a tenth below the limit so pruning, and the directory scan it
+// needs, happens every few hundred writes rather than on every one.
+func (c *responseCache) pruneDisk() {
+	if c.dir == "" {
+		return
+	}
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		logger.Error("Failed to list cache directory: %v", err)
+		return
+	}
+
+	type diskFile struct {
+		path    string
+		created time.Time
+	}
+	files := make([]diskFile, 0, len(paths))
+	expired := 0
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if c.ttl > 0 && c.now().Sub(info.ModTime()) > c.ttl {
+			if c.removeFile(path) {
+				expired++
+			}
+			continue
+		}
+		files = append(files, diskFile{path: path, created: info.ModTime()})
+	}
+
+	evicted := 0
+	if len(files) > c.maxDiskEntries {
+		sort.Slice(files, func(i, j int) bool { return files[i].created.Before(files[j].created) })
+		keep := max(c.maxDiskEntries-c.maxDiskEntries/10, 1)
+		for _, file := range files[:len(files)-keep] {
+			if c.removeFile(file.path) {
+				evicted++
+			}
+		}
+		files = files[len(files)-keep:]
+	}
+	c.diskEntries = len(files)
+	if expired > 0 || evicted > 0 {
+		logger.Info("Pruned cache directory: %d expired, %d over the limit", expired, evicted)
+	}
+}
+
+// removeFile deletes a cache file and reports whether it is gone
+func (c *responseCache) removeFile(path string) bool {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Error("Failed to remove cache entry: %v", err)
+		return false
+	}
+	return true
+}
+
+// cacheLookup returns a cached response for a request unless caching is off or bypassed
+func (c *Client) cacheLookup(req Request) (*Response, bool) {
+	if c.cache == nil || req.NoCache {
+		return nil, false
+	}
+	resp, ok := c.cache.get(cacheKey(req))
+	if ok {
+		logger.Info("Using cached response for %s", req.Model)
+	}
+	return resp, ok
+}
+
+// cacheStore caches a successful response. Bypassed requests still refresh
+// the cache so later identical requests see the new reply.
+func (c *Client) cacheStore(req Request, resp *Response) {
+	if c.cache == nil || resp.Text == "" {
+		return
+	}
+	c.cache.put(cacheKey(req), resp)
+}
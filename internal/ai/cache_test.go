@@ -0,0 +1,148 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"axon/internal/config"
+)
+
+// newCachingClient creates a client with the response cache enabled, answered by a fakeProvider
+func newCachingClient(t *testing.T, provider *fakeProvider, cache config.CacheConfig) *Client {
+	t.Helper()
+	providerType := "test_cache_" + t.Name()
+	RegisterProvider(providerType, func(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+		return provider, nil
+	})
+	cache.Enabled = true
+	return NewClientFromConfig(config.AIConfig{
+		DefaultProvider: "fake",
+		Providers: map[string]config.ProviderConfig{
+			"fake": {Type: providerType},
+		},
+		Cache: cache,
+	})
+}
+
+func TestResponseCacheHitsAndBypass(t *testing.T) {
+	provider := &fakeProvider{reply: "A quiet road."}
+	client := newCachingClient(t, provider, config.CacheConfig{})
+	req := Request{Prompt: "look", Model: "m", Context: []string{"World: Hollowmere"}}
+
+	first, _ := client.Generate(context.Background(), req)
+	second, _ := client.Generate(context.Background(), req)
+	if len(provider.requests) != 1 {
+		t.Fatalf("Expected identical request to be served from cache, provider saw %d", len(provider.requests))
+	}
+	if first.Cached || !second.Cached || second.Text != "A quiet road." {
+		t.Errorf("Unexpected cache flags or text: %+v / %+v", first, second)
+	}
+
+	changed := req
+	changed.Context = []string{"World: Elsewhere"}
+	if resp, _ := client.Generate(context.Background(), changed); resp.Cached {
+		t.Error("Expected a different context to miss the cache")
+	}
+
+	bypass := req
+	bypass.NoCache = true
+	if resp, _ := client.Generate(context.Background(), bypass); resp.Cached {
+		t.Error("Expected NoCache to skip the cache")
+	}
+	if len(provider.requests) != 3 {
+		t.Errorf("Expected 3 provider requests, got %d", len(provider.requests))
+	}
+
+	var streamed string
+	resp, _ := client.GenerateStream(context.Background(), req, func(delta string) { streamed += delta })
+	if !resp.Cached || streamed != "A quiet road." {
+		t.Errorf("Expected cached stream to deliver the whole reply, got %q", streamed)
+	}
+}
+
+func TestResponseCacheSkipsFailures(t *testing.T) {
+	provider := &fakeProvider{reply: ""}
+	client := newCachingClient(t, provider, config.CacheConfig{})
+	req := Request{Prompt: "look", Model: "m"}
+
+	_, _ = client.Generate(context.Background(), req)
+	_, _ = client.Generate(context.Background(), req)
+	if len(provider.requests) != 2 {
+		t.Errorf("Expected empty replies not to be cached, provider saw %d", len(provider.requests))
+	}
+}
+
+func TestResponseCacheDiskAndTTL(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.CacheConfig{Dir: dir, TTLSeconds: 60}
+	req := Request{Prompt: "look", Model: "m"}
+
+	client := newCachingClient(t, &fakeProvider{reply: "Stored"}, cfg)
+	_, _ = client.Generate(context.Background(), req)
+	if _, err := os.Stat(filepath.Join(dir, cacheKey(req)+".json")); err != nil {
+		t.Fatalf("Expected response to be written to disk: %v", err)
+	}
+
+	// A fresh client, as after a restart, finds the response on disk
+	reloaded := newResponseCache(config.CacheConfig{Enabled: true, Dir: dir, TTLSeconds: 60})
+	resp, ok := reloaded.get(cacheKey(req))
+	if !ok || resp.Text != "Stored" {
+		t.Fatalf("Expected disk hit, got %+v, %v", resp, ok)
+	}
+
+	expired := newResponseCache(config.CacheConfig{Enabled: true, Dir: dir, TTLSeconds: 60})
+	expired.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, ok := expired.get(cacheKey(req)); ok {
+		t.Error("Expected entry past its TTL to miss")
+	}
+	if _, err := os.Stat(filepath.Join(dir, cacheKey(req)+".json")); !os.IsNotExist(err) {
+		t.Errorf("Expected expired entry to be removed from disk, got %v", err)
+	}
+}
+
+func TestResponseCachePrunesDisk(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.CacheConfig{Enabled: true, Dir: dir, TTLSeconds: 60, MaxDiskEntries: 2}
+	start := time.Now().Add(-time.Hour)
+
+	cache := newResponseCache(cfg)
+	for i, key := range []string{"a", "b", "c"} {
+		cache.now = func() time.Time { return start.Add(time.Duration(i) * time.Second) }
+		cache.put(key, &Response{Text: key})
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected oldest file past the disk limit to be removed, got %v", err)
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, err := os.Stat(filepath.Join(dir, key+".json")); err != nil {
+			t.Errorf("Expected %s to stay on disk: %v", key, err)
+		}
+	}
+
+	// Opening the cache after the TTL clears the directory without any lookups
+	newResponseCache(cfg)
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+		t.Errorf("Expected expired files to be pruned on open, found %v", files)
+	}
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResponseCache(config.CacheConfig{Enabled: true, MaxEntries: 2})
+	cache.put("a", &Response{Text: "A"})
+	cache.put("b", &Response{Text: "B"})
+	cache.get("a")
+	cache.put("c", &Response{Text: "C"})
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("Expected least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("Expected %s to stay cached", key)
+		}
+	}
+}
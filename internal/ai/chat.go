@@ -0,0 +1,259 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"axon/internal/logger"
+)
+
+// chatCompletionsProvider generates content from an OpenAI-compatible
+// /chat/completions endpoint. OpenRouter and local model servers share it.
+type chatCompletionsProvider struct {
+	// name identifies the provider in errors; label is used in log messages
+	name  string
+	label string
+	// requireKey rejects requests when no API key is configured
+	apiKey     string
+	requireKey bool
+	baseURL    string
+	// modelPrefix is stripped from model names before they are sent
+	modelPrefix string
+	// headers are extra headers sent with every request
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// Generate generates content with a chat completions request
+func (p *chatCompletionsProvider) Generate(ctx context.Context, req Request) (*Response, error) {
+	logger.Debug("Starting %s request", p.label)
+
+	if p.requireKey && p.apiKey == "" {
+		logger.Error("%s API key not configured", p.label)
+		return nil, missingKeyError(p.label)
+	}
+
+	request, err := p.newHTTPRequest(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("Sending %s HTTP request", p.label)
+	resp, err := p.httpClient.Do(request)
+	if err != nil {
+		logger.Error("%s HTTP request failed: %v", p.label, err)
+		return nil, err
+	}
+
+	logger.Debug("%s response status: %s", p.label, resp.Status)
+	defer closeBody(resp, p.label)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error("Failed to read %s response: %v", p.label, err)
+		return nil, err
+	}
+
+	logger.Debug("%s response body: %s", p.label, string(body))
+
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("%s API error: %s - %s", p.label, resp.Status, string(body))
+		return nil, newAPIError(p.name, resp, string(body))
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage *chatUsage `json:"usage"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		logger.Error("Failed to parse %s response: %v", p.label, err)
+		return nil, malformedError("%v", err)
+	}
+
+	logger.Debug("Parsed %s response: %+v", p.label, result)
+
+	if len(result.Choices) == 0 {
+		logger.Error("No choices in %s response", p.label)
+		return nil, errEmptyReply
+	}
+
+	response := &Response{Text: result.Choices[0].Message.Content, Usage: result.Usage.toUsage()}
+	logger.Info("%s request completed successfully", p.label)
+	logger.Debug("Extracted content: %s", response.Text)
+	return response, nil
+}
+
+// GenerateStream generates content from a server-sent event stream of chat completion chunks
+func (p *chatCompletionsProvider) GenerateStream(ctx context.Context, req Request, onDelta StreamHandler) (*Response, error) {
+	logger.Debug("Starting %s stream", p.label)
+
+	if p.requireKey && p.apiKey == "" {
+		logger.Error("%s API key not configured", p.label)
+		return nil, missingKeyError(p.label)
+	}
+
+	request, err := p.newHTTPRequest(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(request)
+	if err != nil {
+		logger.Error("%s HTTP request failed: %v", p.label, err)
+		return nil, err
+	}
+
+	logger.Debug("%s stream status: %s", p.label, resp.Status)
+	defer closeBody(resp, p.label)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		logger.Error("%s API error: %s - %s", p.label, resp.Status, string(body))
+		return nil, newAPIError(p.name, resp, string(body))
+	}
+
+	var text strings.Builder
+	var usage Usage
+	err = readSSE(resp.Body, func(data string) (bool, error) {
+		if data == "[DONE]" {
+			return true, nil
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			// Usage arrives on the final chunk when include_usage is requested
+			Usage *chatUsage `json:"usage"`
+			Error *struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, malformedError("stream chunk: %v", err)
+		}
+		if chunk.Error != nil {
+			return false, &APIError{Provider: p.name, StatusCode: chunk.Error.Code, Message: chunk.Error.Message}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.toUsage()
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				onDelta(choice.Delta.Content)
+			}
+		}
+		return false, nil
+	})
+
+	response := &Response{Text: text.String(), Usage: usage}
+	if err != nil {
+		logger.Error("%s stream interrupted after %d bytes: %v", p.label, text.Len(), err)
+		return response, err
+	}
+	if text.Len() == 0 {
+		return response, errEmptyReply
+	}
+
+	logger.Info("%s stream completed successfully", p.label)
+	return response, nil
+}
+
+// newHTTPRequest builds a chat completions request
+func (p *chatCompletionsProvider) newHTTPRequest(ctx context.Context, req Request, stream bool) (*http.Request, error) {
+	// Build messages from context and prompt
+	messages := make([]map[string]string, 0)
+	for _, ctx := range req.Context {
+		messages = append(messages, map[string]string{
+			"role":    "system",
+			"content": ctx,
+		})
+	}
+	messages = append(messages, map[string]string{
+		"role":    "user",
+		"content": req.Prompt,
+	})
+
+	payload := map[string]interface{}{
+		"model":      strings.TrimPrefix(req.Model, p.modelPrefix),
+		"messages":   messages,
+		"max_tokens": req.MaxTokens,
+		"stream":     stream,
+	}
+	if stream {
+		payload["stream_options"] = map[string]bool{"include_usage": true}
+	}
+	if req.JSON {
+		payload["response_format"] = map[string]string{"type": "json_object"}
+	}
+	if req.Temperature != nil {
+		payload["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		payload["top_p"] = *req.TopP
+	}
+
+	logger.Debug("%s payload: %+v", p.label, payload)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.Error("Failed to marshal %s request: %v", p.label, err)
+		return nil, err
+	}
+
+	logger.Debug("%s request JSON: %s", p.label, string(data))
+
+	request, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewBuffer(data))
+	if err != nil {
+		logger.Error("Failed to create %s HTTP request: %v", p.label, err)
+		return nil, err
+	}
+
+	if p.apiKey != "" {
+		request.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range p.headers {
+		request.Header.Set(name, value)
+	}
+	if stream {
+		request.Header.Set("Accept", "text/event-stream")
+	}
+
+	return request, nil
+}
+
+// chatUsage is the token usage block of a chat completions response
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// toUsage converts a usage block, which servers may omit, into a Usage
+func (u *chatUsage) toUsage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
+// closeBody closes an HTTP response body, logging rather than failing on error
+func closeBody(resp *http.Response, provider string) {
+	if err := resp.Body.Close(); err != nil {
+		logger.Error("Failed to close %s response body: %v", provider, err)
+	}
+}
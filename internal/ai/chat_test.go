@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"axon/internal/config"
+)
+
+func TestChatCompletionsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"Hi"}}],` +
+			`"usage":{"prompt_tokens":120,"completion_tokens":30,"total_tokens":150}}`))
+	}))
+	defer server.Close()
+
+	provider, err := newLocalProvider(config.ProviderConfig{BaseURL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := provider.Generate(context.Background(), Request{Prompt: "hi", Model: "mistral"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Usage != (Usage{PromptTokens: 120, CompletionTokens: 30}) {
+		t.Errorf("Unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestChatCompletionsStreamUsage(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n" +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":80,\"completion_tokens\":5}}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := newLocalProvider(config.ProviderConfig{BaseURL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := provider.(StreamingProvider).GenerateStream(context.Background(), Request{Prompt: "hi", Model: "mistral"},
+		func(string) {})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Usage != (Usage{PromptTokens: 80, CompletionTokens: 5}) {
+		t.Errorf("Unexpected usage %+v", resp.Usage)
+	}
+	options, _ := payload["stream_options"].(map[string]interface{})
+	if options["include_usage"] != true {
+		t.Errorf("Expected streams to request usage, got %v", payload["stream_options"])
+	}
+}
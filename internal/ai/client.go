@@ -1,38 +1,124 @@
 package ai
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
 	"net/http"
-	"strings"
+	"path"
 	"time"
 
+	"axon/internal/config"
 	"axon/internal/logger"
 )
 
 const (
-	// Default model for free tier usage
+	// Model used when neither the task nor default_model names one
 	defaultModel = "mistralai/mistral-7b-instruct:free"
+	// Provider used when no routing rule matches a model
+	defaultProviderName = "openrouter"
+	// Deadline applied to requests whose context carries none
+	defaultRequestTimeout = 30 * time.Second
+	// Prompt context budget for models without a configured one
+	defaultContextTokens = 3000
 )
 
 // Client represents an AI API client
 type Client struct {
-	openRouterKey string
-	geminiKey     string
-	httpClient    *http.Client
+	providers       map[string]Provider
+	modelProviders  map[string]string
+	defaultProvider string
+	failover        map[string][]string
+	contextTokens   map[string]int
+	tasks           map[string]config.TaskConfig
+	pricing         map[string]config.ModelPrice
+	cache           *responseCache
+	rateLimiters    map[string]*rateLimiter
+	defaultModel    string
+	retry           retryPolicy
+	requestTimeout  time.Duration
+	httpClient      *http.Client
 }
 
-// NewClient creates a new AI client
+// NewClient creates a new AI client with the built-in OpenRouter and Gemini
+// providers and the default model routes
 func NewClient(openRouterKey, geminiKey string) *Client {
-	return &Client{
-		openRouterKey: openRouterKey,
-		geminiKey:     geminiKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	return NewClientFromConfig(config.AIConfig{
+		OpenRouterAPIKey: openRouterKey,
+		GeminiAPIKey:     geminiKey,
+		ModelProviders:   config.DefaultModelProviders(),
+	})
+}
+
+// NewClientFromConfig creates a new AI client with providers and routing taken from configuration
+func NewClientFromConfig(cfg config.AIConfig) *Client {
+	c := &Client{
+		providers:       make(map[string]Provider),
+		modelProviders:  make(map[string]string),
+		defaultProvider: cfg.DefaultProvider,
+		failover:        cfg.Failover,
+		contextTokens:   cfg.ContextTokens,
+		tasks:           cfg.Tasks,
+		pricing:         cfg.Pricing,
+		cache:           newResponseCache(cfg.Cache),
+		rateLimiters:    make(map[string]*rateLimiter),
+		defaultModel:    cfg.DefaultModel,
+		retry:           newRetryPolicy(cfg.Retry),
+		requestTimeout:  defaultRequestTimeout,
+		// Deadlines come from the request context so streams are not cut short
+		httpClient: &http.Client{},
+	}
+	if c.defaultProvider == "" {
+		c.defaultProvider = defaultProviderName
 	}
+	if c.defaultModel == "" {
+		c.defaultModel = defaultModel
+	}
+
+	for pattern, name := range cfg.ModelProviders {
+		c.modelProviders[pattern] = name
+	}
+
+	// Providers are built for every configured instance, and for every
+	// registered provider type that a route or the default provider names
+	providerConfigs := make(map[string]config.ProviderConfig, len(cfg.Providers))
+	for name, providerCfg := range cfg.Providers {
+		providerConfigs[name] = providerCfg
+	}
+	referenced := []string{c.defaultProvider}
+	for _, name := range c.modelProviders {
+		referenced = append(referenced, name)
+	}
+	for _, name := range referenced {
+		if _, ok := providerConfigs[name]; !ok && providerRegistered(name) {
+			providerConfigs[name] = config.ProviderConfig{}
+		}
+	}
+
+	// Providers of the built-in types pick up the top-level API keys unless overridden
+	topLevelKeys := map[string]string{
+		"openrouter": cfg.OpenRouterAPIKey,
+		"gemini":     cfg.GeminiAPIKey,
+	}
+	for name, providerCfg := range providerConfigs {
+		providerType := providerCfg.Type
+		if providerType == "" {
+			providerType = name
+		}
+		if providerCfg.APIKey == "" {
+			providerCfg.APIKey = topLevelKeys[providerType]
+		}
+		provider, err := newProvider(providerType, providerCfg, c.httpClient)
+		if err != nil {
+			logger.Error("Failed to configure AI provider %s: %v", name, err)
+			continue
+		}
+		c.providers[name] = provider
+	}
+
+	for pattern, limit := range cfg.RateLimits {
+		c.rateLimiters[pattern] = newRateLimiter(limit)
+	}
+
+	return c
 }
 
 // Request represents an AI request
@@ -41,170 +127,145 @@ type Request struct {
 	Model     string
 	MaxTokens int
 	Context   []string
+	// Task names the kind of work (e.g. "storytelling") and selects its failover models
+	Task string
+	// JSON asks the provider to constrain the reply to a JSON object
+	JSON bool
+	// Temperature and TopP override the provider's sampling defaults when set
+	Temperature *float64
+	TopP        *float64
+	// NoCache skips the response cache lookup; the fresh reply is still cached
+	NoCache bool
 }
 
 // Response represents an AI response
 type Response struct {
-	Text  string
-	Error error
+	Text string
+	// Model is the model that produced the response, which may be a failover model
+	Model string
+	// Usage is the token count reported by the provider, zero when it reports none
+	Usage Usage
+	// Cached is set when the response was served from the response cache
+	Cached bool
+}
+
+// Usage is the number of tokens a request consumed
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
 }
 
-// Generate generates content using the specified AI model
-func (c *Client) Generate(req Request) (*Response, error) {
+// Generate generates content using the specified AI model. Cancelling ctx
+// aborts the request; without a deadline on ctx a default timeout applies to
+// each attempt.
+// Transient failures are retried and then handed to the task's failover models.
+// The response is never nil; when err is set it may still carry partial text.
+// Failures can be told apart with errors.Is against ErrAuth, ErrRateLimited,
+// ErrQuota, ErrContentFiltered, ErrTimeout and ErrMalformed.
+func (c *Client) Generate(ctx context.Context, req Request) (*Response, error) {
 	logger.Info("Starting AI generation with model: %s", req.Model)
 	logger.LogRequest(req)
 
-	var resp *Response
-	var err error
-
-	if strings.HasPrefix(req.Model, "google/") {
-		logger.Debug("Using Gemini provider")
-		resp, err = c.generateGemini(req)
-	} else {
-		logger.Debug("Using OpenRouter provider")
-		resp, err = c.generateOpenRouter(req)
+	if resp, ok := c.cacheLookup(req); ok {
+		return resp, nil
 	}
 
+	resp, err := c.generateWithRetry(ctx, req, func(ctx context.Context, provider Provider, req Request) (*Response, error) {
+		return provider.Generate(ctx, req)
+	})
+
 	if err != nil {
 		logger.Error("AI generation failed: %v", err)
 	} else {
 		logger.Info("AI generation completed")
 		logger.LogResponse(resp)
+		c.cacheStore(req, resp)
 	}
 
 	return resp, err
 }
 
-// generateOpenRouter generates content using OpenRouter API
-func (c *Client) generateOpenRouter(req Request) (*Response, error) {
-	logger.Debug("Starting OpenRouter request")
-
-	if c.openRouterKey == "" {
-		logger.Error("OpenRouter API key not configured")
-		return &Response{Error: fmt.Errorf("OpenRouter API key not configured")}, nil
-	}
-
-	// Build messages from context and prompt
-	messages := make([]map[string]string, 0)
-	for _, ctx := range req.Context {
-		messages = append(messages, map[string]string{
-			"role":    "system",
-			"content": ctx,
-		})
-	}
-	messages = append(messages, map[string]string{
-		"role":    "user",
-		"content": req.Prompt,
-	})
-
-	payload := map[string]interface{}{
-		"model":      req.Model,
-		"messages":   messages,
-		"max_tokens": req.MaxTokens,
-		"stream":     false, // Explicitly disable streaming
-	}
-
-	logger.Debug("OpenRouter payload: %+v", payload)
-
-	data, err := json.Marshal(payload)
-	if err != nil {
-		logger.Error("Failed to marshal OpenRouter request: %v", err)
-		return &Response{Error: err}, nil
+// withDefaultTimeout applies the client's request timeout unless ctx already has a deadline
+func (c *Client) withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, c.requestTimeout)
+}
 
-	logger.Debug("OpenRouter request JSON: %s", string(data))
-
-	logger.Debug("Creating OpenRouter HTTP request")
-	request, err := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(data))
-	if err != nil {
-		logger.Error("Failed to create OpenRouter HTTP request: %v", err)
-		return &Response{Error: err}, nil
+// providerFor resolves the provider responsible for a model. Exact model
+// names win over glob patterns, and longer patterns win over shorter ones.
+func (c *Client) providerFor(model string) (string, Provider) {
+	name, ok := matchModel(c.modelProviders, model)
+	if !ok {
+		name = c.defaultProvider
 	}
+	return name, c.providers[name]
+}
 
-	request.Header.Set("Authorization", "Bearer "+c.openRouterKey)
-	request.Header.Set("Content-Type", "application/json")
-	request.Header.Set("HTTP-Referer", "https://github.com/axon-game")
-	request.Header.Set("X-Title", "Axon Game")
+// matchModel looks a model up in a map keyed by model names or glob
+// patterns. Exact names win over patterns, and longer patterns win over
+// shorter ones.
+func matchModel[V any](entries map[string]V, model string) (V, bool) {
+	key, ok := matchKey(entries, model)
+	return entries[key], ok
+}
 
-	logger.Debug("Sending OpenRouter HTTP request")
-	resp, err := c.httpClient.Do(request)
-	if err != nil {
-		logger.Error("OpenRouter HTTP request failed: %v", err)
-		return &Response{Error: err}, nil
+// matchKey returns the entry key matchModel picks for a model
+func matchKey[V any](entries map[string]V, model string) (string, bool) {
+	if _, ok := entries[model]; ok {
+		return model, true
 	}
 
-	logger.Debug("OpenRouter response status: %s", resp.Status)
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			// Log error but don't fail the request
-			fmt.Printf("Warning: failed to close response body: %v\n", err)
+	bestPattern := ""
+	for pattern := range entries {
+		matched, err := path.Match(pattern, model)
+		if err != nil || !matched {
+			continue
+		}
+		if len(pattern) > len(bestPattern) || (len(pattern) == len(bestPattern) && pattern < bestPattern) {
+			bestPattern = pattern
 		}
-	}()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logger.Error("Failed to read OpenRouter response: %v", err)
-		return &Response{Error: err}, nil
-	}
-
-	logger.Debug("OpenRouter response body: %s", string(body))
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Error("OpenRouter API error: %s - %s", resp.Status, string(body))
-		return &Response{Error: fmt.Errorf("API error: %s", string(body))}, nil
-	}
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
 	}
+	return bestPattern, bestPattern != ""
+}
 
-	if err := json.Unmarshal(body, &result); err != nil {
-		logger.Error("Failed to parse OpenRouter response: %v", err)
-		return &Response{Error: err}, nil
+// ContextBudget returns the number of prompt context tokens to spend on a model
+func (c *Client) ContextBudget(model string) int {
+	if budget, ok := matchModel(c.contextTokens, model); ok && budget > 0 {
+		return budget
 	}
+	return defaultContextTokens
+}
 
-	logger.Debug("Parsed OpenRouter response: %+v", result)
-
-	if len(result.Choices) == 0 {
-		logger.Error("No choices in OpenRouter response")
-		return &Response{Error: fmt.Errorf("no response from API")}, nil
+// GetBestModel returns the model configured for a task, falling back to the default model
+func (c *Client) GetBestModel(task string) string {
+	if model := c.tasks[task].Model; model != "" {
+		return model
 	}
-
-	response := &Response{Text: result.Choices[0].Message.Content}
-	logger.Info("OpenRouter request completed successfully")
-	logger.Debug("Extracted content: %s", response.Text)
-	return response, nil
+	return c.defaultModel
 }
 
-// generateGemini generates content using Gemini API (placeholder implementation)
-func (c *Client) generateGemini(req Request) (*Response, error) {
-	if c.geminiKey == "" {
-		return &Response{Error: fmt.Errorf("gemini API key not configured")}, nil
+// TaskRequest starts a request for a task with its configured model, token
+// limit and sampling settings. MaxTokens is zero when the task sets no limit.
+func (c *Client) TaskRequest(task string) Request {
+	taskCfg := c.tasks[task]
+	return Request{
+		Task:        task,
+		Model:       c.GetBestModel(task),
+		MaxTokens:   taskCfg.MaxTokens,
+		Temperature: taskCfg.Temperature,
+		TopP:        taskCfg.TopP,
 	}
-
-	// This is a simplified implementation - in practice you'd use the actual Gemini API
-	return &Response{
-		Text: "[Gemini response placeholder - implement actual Gemini API integration]",
-	}, nil
 }
 
-// GetBestModel returns the best model for a specific task
-func (c *Client) GetBestModel(task string) string {
-	// Use fastest free models for reliable response times
-	switch task {
-	case "world_building":
-		return defaultModel // Very fast free model
-	case "storytelling":
-		return defaultModel
-	case "rule_setting":
-		return defaultModel
-	case "dialog":
-		return defaultModel
-	default:
-		return defaultModel
+// Cost returns the price in US dollars of the tokens a model consumed.
+// Models without a pricing entry cost nothing.
+func (c *Client) Cost(model string, usage Usage) float64 {
+	price, ok := matchModel(c.pricing, model)
+	if !ok {
+		return 0
 	}
+	return (float64(usage.PromptTokens)*price.PromptPerMillion +
+		float64(usage.CompletionTokens)*price.CompletionPerMillion) / 1e6
 }
@@ -1,10 +1,17 @@
 package ai
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
+
+	"axon/internal/config"
 )
 
 func TestNewClient(t *testing.T) {
@@ -14,16 +21,24 @@ func TestNewClient(t *testing.T) {
 		t.Fatal("NewClient returned nil")
 	}
 
-	if client.openRouterKey != "test_openrouter_key" {
-		t.Errorf("Expected OpenRouter key 'test_openrouter_key', got %s", client.openRouterKey)
+	openRouter, ok := client.providers["openrouter"].(*openRouterProvider)
+	if !ok {
+		t.Fatal("Expected built-in OpenRouter provider")
+	}
+	if openRouter.apiKey != "test_openrouter_key" {
+		t.Errorf("Expected OpenRouter key 'test_openrouter_key', got %s", openRouter.apiKey)
 	}
 
-	if client.geminiKey != "test_gemini_key" {
-		t.Errorf("Expected Gemini key 'test_gemini_key', got %s", client.geminiKey)
+	gemini, ok := client.providers["gemini"].(*geminiProvider)
+	if !ok {
+		t.Fatal("Expected built-in Gemini provider")
+	}
+	if gemini.apiKey != "test_gemini_key" {
+		t.Errorf("Expected Gemini key 'test_gemini_key', got %s", gemini.apiKey)
 	}
 
-	if client.httpClient.Timeout != 30*time.Second {
-		t.Errorf("Expected timeout 30s, got %v", client.httpClient.Timeout)
+	if client.requestTimeout != 30*time.Second {
+		t.Errorf("Expected timeout 30s, got %v", client.requestTimeout)
 	}
 }
 
@@ -60,18 +75,28 @@ func TestGenerateOpenRouterError(t *testing.T) {
 		Context:   []string{"test context"},
 	}
 
-	resp, err := client.Generate(req)
-	if err != nil {
-		t.Errorf("Generate should not return error, got %v", err)
+	resp, err := client.Generate(context.Background(), req)
+	if !errors.Is(err, ErrAuth) {
+		t.Errorf("Expected ErrAuth for missing API key, got %v", err)
 	}
-
-	if resp.Error == nil {
-		t.Error("Expected error for missing API key")
+	if resp == nil || resp.Model != "openai/gpt-4o-mini" {
+		t.Errorf("Expected a response naming the model even on failure, got %+v", resp)
 	}
 }
 
 func TestGenerateGemini(t *testing.T) {
-	client := NewClient("", "test_gemini_key")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"Gemini says hi"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientFromConfig(config.AIConfig{
+		GeminiAPIKey: "test_gemini_key",
+		Providers: map[string]config.ProviderConfig{
+			"gemini": {BaseURL: server.URL},
+		},
+		ModelProviders: config.DefaultModelProviders(),
+	})
 
 	req := Request{
 		Prompt:    "test prompt",
@@ -80,14 +105,14 @@ func TestGenerateGemini(t *testing.T) {
 		Context:   []string{"test context"},
 	}
 
-	resp, err := client.Generate(req)
+	resp, err := client.Generate(context.Background(), req)
 	if err != nil {
 		t.Errorf("Generate should not return error, got %v", err)
 	}
 
-	// Should return placeholder response
-	if resp.Text == "" {
-		t.Error("Expected non-empty response text")
+	// Should be routed to Gemini by the default google/* route
+	if resp.Text != "Gemini says hi" {
+		t.Errorf("Expected Gemini response text, got %q", resp.Text)
 	}
 }
 
@@ -107,11 +132,11 @@ func TestOpenRouterAPICall(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Create client with test key
-	client := NewClient("test_key", "")
+	provider, err := newOpenRouterProvider(config.ProviderConfig{APIKey: "test_key", BaseURL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	// Override the API URL for testing (this would require modifying the client for testing)
-	// For now, we'll test the error case with empty key
 	req := Request{
 		Prompt:    "test prompt",
 		Model:     "openai/gpt-4o-mini",
@@ -119,16 +144,90 @@ func TestOpenRouterAPICall(t *testing.T) {
 		Context:   []string{"test context"},
 	}
 
-	// This will fail because we can't override the URL easily
-	// In a real implementation, you'd make the URL configurable
-	resp, err := client.generateOpenRouter(req)
+	resp, err := provider.Generate(context.Background(), req)
 	if err != nil {
-		t.Errorf("Generate should not return error, got %v", err)
+		t.Fatalf("Generate should not return error, got %v", err)
+	}
+
+	if resp.Text != "Test response" {
+		t.Errorf("Expected 'Test response', got %s", resp.Text)
+	}
+}
+
+// fakeProvider records requests and returns a fixed reply
+type fakeProvider struct {
+	mu       sync.Mutex
+	reply    string
+	requests []Request
+}
+
+func (p *fakeProvider) Generate(ctx context.Context, req Request) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	return &Response{Text: p.reply}, nil
+}
+
+func TestProviderRouting(t *testing.T) {
+	local := &fakeProvider{reply: "local"}
+	remote := &fakeProvider{reply: "remote"}
+	RegisterProvider("test_local", func(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+		return local, nil
+	})
+	RegisterProvider("test_remote", func(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+		return remote, nil
+	})
+
+	client := NewClientFromConfig(config.AIConfig{
+		DefaultProvider: "remote",
+		Providers: map[string]config.ProviderConfig{
+			"local":  {Type: "test_local"},
+			"remote": {Type: "test_remote"},
+		},
+		ModelProviders: map[string]string{
+			"llama*":          "local",
+			"llama3-special":  "remote",
+			"google/*":        "local",
+			"google/gemini-x": "remote",
+		},
+	})
+
+	tests := []struct {
+		model    string
+		expected string
+	}{
+		{"llama3", "local"},
+		{"llama3-special", "remote"},
+		{"google/gemini-pro", "local"},
+		{"google/gemini-x", "remote"},
+		{"openai/gpt-4o-mini", "remote"},
 	}
 
-	// Should attempt to make request (will fail due to real API)
+	for _, test := range tests {
+		resp, err := client.Generate(context.Background(), Request{Prompt: "hi", Model: test.model})
+		if err != nil {
+			t.Fatalf("Generate returned error: %v", err)
+		}
+		if resp.Text != test.expected {
+			t.Errorf("For model %s, expected provider %s, got %s", test.model, test.expected, resp.Text)
+		}
+	}
+}
+
+func TestUnknownProviderType(t *testing.T) {
+	client := NewClientFromConfig(config.AIConfig{
+		DefaultProvider: "missing",
+		Providers: map[string]config.ProviderConfig{
+			"missing": {Type: "does_not_exist"},
+		},
+	})
+
+	resp, err := client.Generate(context.Background(), Request{Prompt: "hi", Model: "any"})
+	if err == nil {
+		t.Error("Expected error for unconfigured provider")
+	}
 	if resp == nil {
-		t.Error("Expected response object")
+		t.Error("Expected a response even on failure")
 	}
 }
 
@@ -157,15 +256,159 @@ func TestRequestValidation(t *testing.T) {
 func TestResponseStruct(t *testing.T) {
 	// Test Response struct
 	resp := &Response{
-		Text:  "test response",
-		Error: nil,
+		Text: "test response",
 	}
 
 	if resp.Text != "test response" {
 		t.Errorf("Expected text 'test response', got %s", resp.Text)
 	}
+}
+
+// blockingProvider waits for its context to end
+type blockingProvider struct{}
+
+func (blockingProvider) Generate(ctx context.Context, req Request) (*Response, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestGenerateCancellation(t *testing.T) {
+	RegisterProvider("test_blocking", func(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+		return blockingProvider{}, nil
+	})
+	client := NewClientFromConfig(config.AIConfig{
+		DefaultProvider: "blocking",
+		Providers: map[string]config.ProviderConfig{
+			"blocking": {Type: "test_blocking"},
+		},
+	})
+
+	// Caller deadlines are honored
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.Generate(ctx, Request{Prompt: "p", Model: "m"})
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected deadline exceeded classed as ErrTimeout, got %v", err)
+	}
+
+	// Without a deadline the client's default timeout applies
+	client.requestTimeout = 10 * time.Millisecond
+	_, err = client.Generate(context.Background(), Request{Prompt: "p", Model: "m"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected default timeout to apply, got %v", err)
+	}
+
+	// Cancellation is not a timeout
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = client.Generate(ctx, Request{Prompt: "p", Model: "m"})
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected plain cancellation, got %v", err)
+	}
+}
+
+func TestContextBudget(t *testing.T) {
+	client := NewClientFromConfig(config.AIConfig{
+		ContextTokens: map[string]int{"small/*": 500, "small/exact": 200},
+	})
+
+	if got := client.ContextBudget("small/model"); got != 500 {
+		t.Errorf("Expected glob budget 500, got %d", got)
+	}
+	if got := client.ContextBudget("small/exact"); got != 200 {
+		t.Errorf("Expected exact budget 200, got %d", got)
+	}
+	if got := client.ContextBudget("other/model"); got != defaultContextTokens {
+		t.Errorf("Expected default budget %d, got %d", defaultContextTokens, got)
+	}
+}
+
+func TestCost(t *testing.T) {
+	client := NewClientFromConfig(config.AIConfig{
+		Pricing: map[string]config.ModelPrice{
+			"openai/*":           {PromptPerMillion: 1, CompletionPerMillion: 2},
+			"openai/gpt-4o-mini": {PromptPerMillion: 0.15, CompletionPerMillion: 0.60},
+		},
+	})
+	usage := Usage{PromptTokens: 2000, CompletionTokens: 500}
+
+	if got := client.Cost("openai/gpt-4o-mini", usage); math.Abs(got-0.0006) > 1e-12 {
+		t.Errorf("Expected exact model price 0.0006, got %v", got)
+	}
+	if got := client.Cost("openai/gpt-4o", usage); math.Abs(got-0.003) > 1e-12 {
+		t.Errorf("Expected glob price 0.003, got %v", got)
+	}
+	if got := client.Cost("unpriced/model", usage); got != 0 {
+		t.Errorf("Expected unpriced model to cost nothing, got %v", got)
+	}
+}
+
+func TestGetBestModelFromConfig(t *testing.T) {
+	client := NewClientFromConfig(config.AIConfig{
+		DefaultModel: "openai/gpt-4o-mini",
+		Tasks: map[string]config.TaskConfig{
+			"dialog": {Model: "anthropic/claude-3-haiku"},
+		},
+	})
+
+	if got := client.GetBestModel("dialog"); got != "anthropic/claude-3-haiku" {
+		t.Errorf("Expected task model, got %s", got)
+	}
+	if got := client.GetBestModel("storytelling"); got != "openai/gpt-4o-mini" {
+		t.Errorf("Expected default model, got %s", got)
+	}
+}
+
+func TestTaskRequest(t *testing.T) {
+	temperature, topP := 0.9, 0.95
+	client := NewClientFromConfig(config.AIConfig{
+		Tasks: map[string]config.TaskConfig{
+			"storytelling": {Model: "meta/llama", Temperature: &temperature, TopP: &topP, MaxTokens: 900},
+		},
+	})
+
+	req := client.TaskRequest("storytelling")
+	if req.Task != "storytelling" || req.Model != "meta/llama" || req.MaxTokens != 900 {
+		t.Errorf("Unexpected request %+v", req)
+	}
+	if req.Temperature == nil || *req.Temperature != 0.9 || req.TopP == nil || *req.TopP != 0.95 {
+		t.Errorf("Sampling settings not applied: %+v", req)
+	}
+
+	req = client.TaskRequest("dialog")
+	if req.Temperature != nil || req.TopP != nil || req.MaxTokens != 0 {
+		t.Errorf("Unconfigured task should leave settings unset: %+v", req)
+	}
+}
+
+func TestSamplingSettingsSent(t *testing.T) {
+	temperature, topP := 0.7, 0.5
+
+	var openRouterPayload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&openRouterPayload); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	openRouter, err := newOpenRouterProvider(config.ProviderConfig{APIKey: "test_key", BaseURL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := Request{Prompt: "p", Model: "m", Temperature: &temperature, TopP: &topP}
+	if _, err := openRouter.Generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if openRouterPayload["temperature"] != 0.7 || openRouterPayload["top_p"] != 0.5 {
+		t.Errorf("OpenRouter payload missing sampling settings: %v", openRouterPayload)
+	}
 
-	if resp.Error != nil {
-		t.Errorf("Expected no error, got %v", resp.Error)
+	body := buildGeminiRequest(req)
+	if body.GenerationConfig.Temperature == nil || *body.GenerationConfig.Temperature != 0.7 ||
+		body.GenerationConfig.TopP == nil || *body.GenerationConfig.TopP != 0.5 {
+		t.Errorf("Gemini generation config missing sampling settings: %+v", body.GenerationConfig)
 	}
 }
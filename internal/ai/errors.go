@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Classes of AI failure. Errors returned by the client match at most one of
+// them with errors.Is; anything else, such as a network failure, matches none.
+var (
+	// ErrAuth means the API key is missing or was rejected
+	ErrAuth = errors.New("authentication failed")
+	// ErrRateLimited means the provider asked for requests to slow down
+	ErrRateLimited = errors.New("rate limited")
+	// ErrQuota means the account has run out of credits or its usage quota
+	ErrQuota = errors.New("quota exhausted")
+	// ErrContentFiltered means the provider's safety filters withheld the prompt or reply
+	ErrContentFiltered = errors.New("content filtered")
+	// ErrTimeout means the request ran out of time
+	ErrTimeout = errors.New("request timed out")
+	// ErrMalformed means the provider's reply could not be understood or was empty
+	ErrMalformed = errors.New("malformed response")
+)
+
+// APIError describes a non-success HTTP response from a provider
+type APIError struct {
+	Provider   string
+	StatusCode int
+	// RetryAfter is the delay requested by the server, zero if none was given
+	RetryAfter time.Duration
+	Message    string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %s", e.Message)
+}
+
+// Is classifies the error by HTTP status so callers can match it against the
+// error classes. Rate limit responses that mention a quota count as ErrQuota.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrAuth:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrQuota:
+		return e.StatusCode == http.StatusPaymentRequired ||
+			(e.StatusCode == http.StatusTooManyRequests && mentionsQuota(e.Message))
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests && !mentionsQuota(e.Message)
+	}
+	return false
+}
+
+// mentionsQuota reports whether a provider message is about an exhausted quota rather than a rate
+func mentionsQuota(message string) bool {
+	lower := strings.ToLower(message)
+	return strings.Contains(lower, "quota") || strings.Contains(lower, "credits")
+}
+
+// malformedError wraps a decoding problem as ErrMalformed
+func malformedError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrMalformed, fmt.Sprintf(format, args...))
+}
+
+// errEmptyReply is returned when a provider answers without any text
+var errEmptyReply = fmt.Errorf("%w: no response from API", ErrMalformed)
+
+// missingKeyError reports a provider with no API key configured
+func missingKeyError(provider string) error {
+	return fmt.Errorf("%w: %s API key not configured", ErrAuth, provider)
+}
+
+// timeoutError marks deadline and network timeouts as ErrTimeout, keeping the original error
+func timeoutError(err error) error {
+	if err == nil || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
+
+// newAPIError builds an APIError from an HTTP response and its body message
+func newAPIError(provider string, resp *http.Response, message string) *APIError {
+	return &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		Message:    message,
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(when); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
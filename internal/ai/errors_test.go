@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"axon/internal/config"
+)
+
+func TestAPIErrorClasses(t *testing.T) {
+	classes := []error{ErrAuth, ErrRateLimited, ErrQuota, ErrContentFiltered, ErrTimeout, ErrMalformed}
+	tests := []struct {
+		status  int
+		message string
+		want    error
+	}{
+		{http.StatusUnauthorized, "No auth credentials found", ErrAuth},
+		{http.StatusForbidden, "Key disabled", ErrAuth},
+		{http.StatusPaymentRequired, "Insufficient credits", ErrQuota},
+		{http.StatusTooManyRequests, "Rate limit exceeded", ErrRateLimited},
+		{http.StatusTooManyRequests, "You exceeded your current quota (RESOURCE_EXHAUSTED)", ErrQuota},
+		{http.StatusBadGateway, "Upstream error", nil},
+	}
+
+	for _, test := range tests {
+		err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: test.status, Message: test.message})
+		for _, class := range classes {
+			if got := errors.Is(err, class); got != (class == test.want) {
+				t.Errorf("%d %q: errors.Is(%v) = %v", test.status, test.message, class, got)
+			}
+		}
+	}
+}
+
+func TestTimeoutError(t *testing.T) {
+	if err := timeoutError(context.DeadlineExceeded); !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline to be classed as ErrTimeout and keep its cause, got %v", err)
+	}
+	if err := timeoutError(context.Canceled); errors.Is(err, ErrTimeout) {
+		t.Errorf("Cancellation should not be a timeout, got %v", err)
+	}
+	if timeoutError(nil) != nil {
+		t.Error("Expected nil to stay nil")
+	}
+}
+
+func TestStreamErrorChunkIsClassified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`data: {"error":{"code":429,"message":"Rate limit exceeded"}}` + "\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewClientFromConfig(config.AIConfig{
+		OpenRouterAPIKey: "k",
+		Providers:        map[string]config.ProviderConfig{"openrouter": {BaseURL: server.URL}},
+	})
+
+	resp, err := client.GenerateStream(context.Background(), Request{Prompt: "p", Model: "m"}, nil)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+	if resp == nil || resp.Model != "m" {
+		t.Errorf("Expected a response naming the model, got %+v", resp)
+	}
+}
@@ -0,0 +1,330 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"axon/internal/config"
+	"axon/internal/logger"
+)
+
+const (
+	// Default Gemini API endpoint
+	defaultGeminiURL = "https://generativelanguage.googleapis.com/v1beta"
+)
+
+func init() {
+	RegisterProvider("gemini", newGeminiProvider)
+}
+
+// geminiProvider generates content using the Gemini API
+type geminiProvider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// newGeminiProvider creates a Gemini provider
+func newGeminiProvider(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultGeminiURL
+	}
+	return &geminiProvider{
+		apiKey:     cfg.APIKey,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}, nil
+}
+
+// geminiPart is a single piece of content in a Gemini message
+type geminiPart struct {
+	Text    string `json:"text"`
+	Thought bool   `json:"thought,omitempty"`
+}
+
+// geminiContent is a Gemini message made of parts
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+// geminiRequest is the generateContent request body
+type geminiRequest struct {
+	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent `json:"contents"`
+	GenerationConfig  struct {
+		MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+		Temperature      *float64 `json:"temperature,omitempty"`
+		TopP             *float64 `json:"topP,omitempty"`
+		ResponseMimeType string   `json:"responseMimeType,omitempty"`
+	} `json:"generationConfig"`
+}
+
+// geminiResponse is the generateContent response body
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// usage returns the token counts reported with a response or stream chunk
+func (r *geminiResponse) usage() Usage {
+	if r.UsageMetadata == nil {
+		return Usage{}
+	}
+	return Usage{
+		PromptTokens:     r.UsageMetadata.PromptTokenCount,
+		CompletionTokens: r.UsageMetadata.CandidatesTokenCount,
+	}
+}
+
+// geminiErrorBody is the error envelope returned on non-200 responses
+type geminiErrorBody struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// Finish reasons that mean the candidate was withheld by Gemini's filters
+var geminiBlockedReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
+// Generate generates content using the Gemini generateContent API
+func (p *geminiProvider) Generate(ctx context.Context, req Request) (*Response, error) {
+	logger.Debug("Starting Gemini request")
+
+	if p.apiKey == "" {
+		logger.Error("Gemini API key not configured")
+		return nil, missingKeyError("gemini")
+	}
+
+	data, err := json.Marshal(buildGeminiRequest(req))
+	if err != nil {
+		logger.Error("Failed to marshal Gemini request: %v", err)
+		return nil, err
+	}
+
+	logger.Debug("Gemini request JSON: %s", string(data))
+
+	url := fmt.Sprintf("%s/models/%s:generateContent", p.baseURL, geminiModelName(req.Model))
+	request, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
+	if err != nil {
+		logger.Error("Failed to create Gemini HTTP request: %v", err)
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := p.httpClient.Do(request)
+	if err != nil {
+		logger.Error("Gemini HTTP request failed: %v", err)
+		return nil, err
+	}
+
+	logger.Debug("Gemini response status: %s", resp.Status)
+	defer closeBody(resp, "Gemini")
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error("Failed to read Gemini response: %v", err)
+		return nil, err
+	}
+
+	logger.Debug("Gemini response body: %s", string(body))
+
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("Gemini API error: %s - %s", resp.Status, string(body))
+		return nil, geminiAPIError(resp, body)
+	}
+
+	var result geminiResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		logger.Error("Failed to parse Gemini response: %v", err)
+		return nil, malformedError("%v", err)
+	}
+
+	text, err := extractGeminiText(&result)
+	if err != nil {
+		logger.Error("Gemini returned no usable content: %v", err)
+		return nil, err
+	}
+
+	logger.Info("Gemini request completed successfully")
+	logger.Debug("Extracted content: %s", text)
+	return &Response{Text: text, Usage: result.usage()}, nil
+}
+
+// GenerateStream generates content using Gemini's streamGenerateContent SSE endpoint
+func (p *geminiProvider) GenerateStream(ctx context.Context, req Request, onDelta StreamHandler) (*Response, error) {
+	logger.Debug("Starting Gemini stream")
+
+	if p.apiKey == "" {
+		logger.Error("Gemini API key not configured")
+		return nil, missingKeyError("gemini")
+	}
+
+	data, err := json.Marshal(buildGeminiRequest(req))
+	if err != nil {
+		logger.Error("Failed to marshal Gemini request: %v", err)
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", p.baseURL, geminiModelName(req.Model))
+	request, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
+	if err != nil {
+		logger.Error("Failed to create Gemini HTTP request: %v", err)
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := p.httpClient.Do(request)
+	if err != nil {
+		logger.Error("Gemini HTTP request failed: %v", err)
+		return nil, err
+	}
+
+	logger.Debug("Gemini stream status: %s", resp.Status)
+	defer closeBody(resp, "Gemini")
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		logger.Error("Gemini API error: %s - %s", resp.Status, string(body))
+		return nil, geminiAPIError(resp, body)
+	}
+
+	var text strings.Builder
+	var usage Usage
+	err = readSSE(resp.Body, func(data string) (bool, error) {
+		var chunk geminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, malformedError("stream chunk: %v", err)
+		}
+		// Each chunk reports the running total, so the last one wins
+		if chunk.UsageMetadata != nil {
+			usage = chunk.usage()
+		}
+
+		delta, err := extractGeminiText(&chunk)
+		if err != nil {
+			// Intermediate chunks, and a closing chunk with only usage metadata,
+			// may legitimately carry no text; only safety blocks end the stream.
+			// A stream with no text at all is reported once it ends.
+			if errors.Is(err, ErrContentFiltered) {
+				return false, err
+			}
+			return false, nil
+		}
+
+		text.WriteString(delta)
+		onDelta(delta)
+		return false, nil
+	})
+
+	response := &Response{Text: text.String(), Usage: usage}
+	if err != nil {
+		logger.Error("Gemini stream interrupted after %d bytes: %v", text.Len(), err)
+		return response, err
+	}
+	if text.Len() == 0 {
+		return response, errEmptyReply
+	}
+
+	logger.Info("Gemini stream completed successfully")
+	return response, nil
+}
+
+// buildGeminiRequest maps a Request onto the Gemini request body, sending
+// the request context as system instructions
+func buildGeminiRequest(req Request) *geminiRequest {
+	body := &geminiRequest{
+		Contents: []geminiContent{{
+			Role:  "user",
+			Parts: []geminiPart{{Text: req.Prompt}},
+		}},
+	}
+	body.GenerationConfig.MaxOutputTokens = req.MaxTokens
+	body.GenerationConfig.Temperature = req.Temperature
+	body.GenerationConfig.TopP = req.TopP
+	if req.JSON {
+		body.GenerationConfig.ResponseMimeType = "application/json"
+	}
+
+	if len(req.Context) > 0 {
+		parts := make([]geminiPart, 0, len(req.Context))
+		for _, ctx := range req.Context {
+			parts = append(parts, geminiPart{Text: ctx})
+		}
+		body.SystemInstruction = &geminiContent{Parts: parts}
+	}
+
+	return body
+}
+
+// geminiModelName strips routing prefixes such as "google/" from a model name
+func geminiModelName(model string) string {
+	model = strings.TrimPrefix(model, "google/")
+	return strings.TrimPrefix(model, "models/")
+}
+
+// extractGeminiText returns the text of the first candidate, reporting safety blocks as errors
+func extractGeminiText(result *geminiResponse) (string, error) {
+	if reason := result.PromptFeedback.BlockReason; reason != "" {
+		return "", fmt.Errorf("%w: gemini blocked the prompt: %s", ErrContentFiltered, reason)
+	}
+
+	if len(result.Candidates) == 0 {
+		return "", errEmptyReply
+	}
+
+	candidate := result.Candidates[0]
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if part.Thought {
+			continue
+		}
+		text.WriteString(part.Text)
+	}
+
+	if text.Len() == 0 {
+		if geminiBlockedReasons[candidate.FinishReason] {
+			return "", fmt.Errorf("%w: gemini blocked the response: %s", ErrContentFiltered, candidate.FinishReason)
+		}
+		return "", malformedError("empty response from API (finish reason: %s)", candidate.FinishReason)
+	}
+
+	return text.String(), nil
+}
+
+// geminiAPIError builds an error from a Gemini error body, falling back to the raw body
+func geminiAPIError(resp *http.Response, body []byte) error {
+	var errBody geminiErrorBody
+	if err := json.Unmarshal(body, &errBody); err == nil && errBody.Error.Message != "" {
+		return newAPIError("gemini", resp, fmt.Sprintf("%s (%s)", errBody.Error.Message, errBody.Error.Status))
+	}
+	return newAPIError("gemini", resp, fmt.Sprintf("%s - %s", resp.Status, string(body)))
+}
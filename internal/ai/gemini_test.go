@@ -0,0 +1,163 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"axon/internal/config"
+)
+
+// newTestGemini creates a Gemini provider pointed at a test server
+func newTestGemini(t *testing.T, handler http.HandlerFunc) *geminiProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	provider, err := newGeminiProvider(config.ProviderConfig{APIKey: "test_key", BaseURL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider.(*geminiProvider)
+}
+
+func TestGeminiGenerate(t *testing.T) {
+	var received geminiRequest
+	provider := newTestGemini(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-pro:generateContent" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "test_key" {
+			t.Errorf("Expected API key header, got %q", r.Header.Get("x-goog-api-key"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[` +
+			`{"text":"thinking","thought":true},{"text":"Hello "},{"text":"traveler"}]},"finishReason":"STOP"}]}`))
+	})
+
+	resp, err := provider.Generate(context.Background(), Request{
+		Prompt:    "test prompt",
+		Model:     "google/gemini-pro",
+		MaxTokens: 100,
+		Context:   []string{"rule one", "rule two"},
+	})
+	if err != nil {
+		t.Fatalf("Generate should not return error, got %v", err)
+	}
+	if resp.Text != "Hello traveler" {
+		t.Errorf("Expected 'Hello traveler', got %q", resp.Text)
+	}
+
+	if received.SystemInstruction == nil || len(received.SystemInstruction.Parts) != 2 {
+		t.Fatalf("Expected context as two system instruction parts, got %+v", received.SystemInstruction)
+	}
+	if received.SystemInstruction.Parts[0].Text != "rule one" {
+		t.Errorf("Unexpected system instruction: %s", received.SystemInstruction.Parts[0].Text)
+	}
+	if len(received.Contents) != 1 || received.Contents[0].Parts[0].Text != "test prompt" {
+		t.Errorf("Unexpected contents: %+v", received.Contents)
+	}
+	if received.GenerationConfig.MaxOutputTokens != 100 {
+		t.Errorf("Expected maxOutputTokens 100, got %d", received.GenerationConfig.MaxOutputTokens)
+	}
+}
+
+func TestGeminiUsage(t *testing.T) {
+	provider := newTestGemini(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"candidates":[{"content":{"parts":[{"text":"Hello"}]}}],` +
+			`"usageMetadata":{"promptTokenCount":40,"candidatesTokenCount":1}}` + "\n\n" +
+			`data: {"candidates":[{"content":{"parts":[{"text":" there"}]},"finishReason":"STOP"}],` +
+			`"usageMetadata":{"promptTokenCount":40,"candidatesTokenCount":2,"totalTokenCount":42}}` + "\n\n"))
+	})
+
+	resp, err := provider.GenerateStream(context.Background(), Request{Prompt: "hi", Model: "gemini-pro"}, func(string) {})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Usage != (Usage{PromptTokens: 40, CompletionTokens: 2}) {
+		t.Errorf("Expected the final chunk's usage, got %+v", resp.Usage)
+	}
+}
+
+func TestGeminiSafetyBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		want  string
+		class error
+	}{
+		{"prompt blocked", `{"promptFeedback":{"blockReason":"SAFETY"}}`, "blocked the prompt", ErrContentFiltered},
+		{"candidate blocked", `{"candidates":[{"content":{"parts":[]},"finishReason":"SAFETY"}]}`, "blocked the response", ErrContentFiltered},
+		{"no candidates", `{"candidates":[]}`, "no response", ErrMalformed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := newTestGemini(t, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(test.body))
+			})
+
+			_, err := provider.Generate(context.Background(), Request{Prompt: "p", Model: "gemini-pro"})
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Expected error containing %q, got %v", test.want, err)
+			}
+			if !errors.Is(err, test.class) {
+				t.Errorf("Expected error classed as %v, got %v", test.class, err)
+			}
+		})
+	}
+}
+
+func TestGeminiErrorBody(t *testing.T) {
+	provider := newTestGemini(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"code":400,"message":"API key not valid","status":"INVALID_ARGUMENT"}}`))
+	})
+
+	_, err := provider.Generate(context.Background(), Request{Prompt: "p", Model: "gemini-pro"})
+	if err == nil || !strings.Contains(err.Error(), "API key not valid") {
+		t.Errorf("Expected API error message, got %v", err)
+	}
+}
+
+func TestGeminiStream(t *testing.T) {
+	provider := newTestGemini(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(
+			`data: {"candidates":[{"content":{"parts":[{"text":"The door "}]}}]}` + "\n\n" +
+				`data: {"candidates":[{"content":{"parts":[{"text":"creaks open."}]},"finishReason":"STOP"}]}` + "\n\n" +
+				// Gemini often closes a stream with a chunk that only reports usage
+				`data: {"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":4}}` + "\n\n"))
+	})
+
+	var streamed strings.Builder
+	resp, err := provider.GenerateStream(context.Background(), Request{Prompt: "open door", Model: "gemini-pro"},
+		func(delta string) { streamed.WriteString(delta) })
+	if err != nil {
+		t.Fatalf("GenerateStream should not return error, got %v", err)
+	}
+	if resp.Text != "The door creaks open." || streamed.String() != resp.Text {
+		t.Errorf("Unexpected text %q, streamed %q", resp.Text, streamed.String())
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 4 {
+		t.Errorf("Expected usage from the closing chunk, got %+v", resp.Usage)
+	}
+
+	// A stream that never produces text is still an error
+	empty := newTestGemini(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"usageMetadata":{"promptTokenCount":12}}` + "\n\n"))
+	})
+	if _, err := empty.GenerateStream(context.Background(), Request{Prompt: "p", Model: "gemini-pro"},
+		func(string) {}); err == nil {
+		t.Error("Expected an error for a stream without text")
+	}
+}
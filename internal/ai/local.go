@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"net/http"
+	"strings"
+
+	"axon/internal/config"
+)
+
+const (
+	// Default local endpoint, Ollama's OpenAI-compatible API
+	defaultLocalURL = "http://localhost:11434/v1"
+	// Prefix that routes a model to the local provider, e.g. "local/llama3.1"
+	localModelPrefix = "local/"
+)
+
+func init() {
+	RegisterProvider("local", newLocalProvider)
+}
+
+// localProvider generates content using a self-hosted OpenAI-compatible
+// server such as Ollama, llama.cpp or vLLM. No API key is required, though
+// one is sent if configured.
+type localProvider struct {
+	*chatCompletionsProvider
+}
+
+// newLocalProvider creates a provider for an OpenAI-compatible endpoint
+func newLocalProvider(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultLocalURL
+	}
+	return &localProvider{&chatCompletionsProvider{
+		name:        "local",
+		label:       "Local model",
+		apiKey:      cfg.APIKey,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		modelPrefix: localModelPrefix,
+		httpClient:  httpClient,
+	}}, nil
+}
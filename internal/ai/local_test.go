@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"axon/internal/config"
+)
+
+func TestLocalProviderWithoutKey(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("No Authorization header expected without a key, got %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"Offline tale"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientFromConfig(config.AIConfig{
+		Providers: map[string]config.ProviderConfig{
+			"local": {BaseURL: server.URL + "/v1/"},
+		},
+		ModelProviders: config.DefaultModelProviders(),
+	})
+
+	resp, err := client.Generate(context.Background(), Request{Prompt: "hello", Model: "local/llama3.1:8b"})
+	if err != nil {
+		t.Fatalf("Generate should not return error, got %v", err)
+	}
+	if resp.Text != "Offline tale" {
+		t.Errorf("Expected 'Offline tale', got %q", resp.Text)
+	}
+	if payload["model"] != "llama3.1:8b" {
+		t.Errorf("Expected routing prefix to be stripped, got %v", payload["model"])
+	}
+}
+
+func TestLocalProviderStreamAndKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("Expected configured key to be sent, got %q", auth)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"Hello \"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"there\"}}]}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := newLocalProvider(config.ProviderConfig{APIKey: "secret", BaseURL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var streamed strings.Builder
+	resp, err := provider.(StreamingProvider).GenerateStream(context.Background(), Request{Prompt: "hi", Model: "mistral"},
+		func(delta string) { streamed.WriteString(delta) })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Text != "Hello there" || streamed.String() != "Hello there" {
+		t.Errorf("Unexpected stream result %q / %q", resp.Text, streamed.String())
+	}
+}
+
+func TestLocalProviderRouting(t *testing.T) {
+	client := NewClient("", "")
+
+	name, provider := client.providerFor("local/phi3")
+	if name != "local" {
+		t.Errorf("Expected local/ models to route to the local provider, got %s", name)
+	}
+	if _, ok := provider.(*localProvider); !ok {
+		t.Errorf("Expected built-in local provider, got %T", provider)
+	}
+}
+
+func TestProvidersComeFromConfig(t *testing.T) {
+	RegisterProvider("test_plugin", func(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+		return &localProvider{&chatCompletionsProvider{name: "plugin", baseURL: cfg.BaseURL}}, nil
+	})
+
+	// A registered backend is used once a route names it, with no client changes
+	client := NewClientFromConfig(config.AIConfig{
+		ModelProviders: map[string]string{"plugin/*": "test_plugin"},
+	})
+	if name, provider := client.providerFor("plugin/tiny"); name != "test_plugin" || provider == nil {
+		t.Errorf("Expected plugin/ models to route to the registered provider, got %s / %v", name, provider)
+	}
+
+	// Backends nothing refers to are not built
+	if _, ok := client.providers["local"]; ok {
+		t.Error("Expected no local provider without a route or configuration")
+	}
+}
@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"net/http"
+	"strings"
+
+	"axon/internal/config"
+)
+
+const (
+	// Default OpenRouter API endpoint
+	defaultOpenRouterURL = "https://openrouter.ai/api/v1"
+)
+
+func init() {
+	RegisterProvider("openrouter", newOpenRouterProvider)
+}
+
+// openRouterProvider generates content using the OpenRouter API
+type openRouterProvider struct {
+	*chatCompletionsProvider
+}
+
+// newOpenRouterProvider creates an OpenRouter provider
+func newOpenRouterProvider(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenRouterURL
+	}
+	return &openRouterProvider{&chatCompletionsProvider{
+		name:       "openrouter",
+		label:      "OpenRouter",
+		apiKey:     cfg.APIKey,
+		requireKey: true,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		headers: map[string]string{
+			"HTTP-Referer": "https://github.com/axon-game",
+			"X-Title":      "Axon Game",
+		},
+		httpClient: httpClient,
+	}}, nil
+}
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"axon/internal/config"
+)
+
+// Provider generates completions from a single AI backend
+type Provider interface {
+	Generate(ctx context.Context, req Request) (*Response, error)
+}
+
+// ProviderFactory builds a provider instance from its configuration
+type ProviderFactory func(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]ProviderFactory)
+)
+
+// RegisterProvider makes a provider type available for use in configuration.
+// Registering an existing type replaces its factory.
+func RegisterProvider(providerType string, factory ProviderFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[providerType] = factory
+}
+
+// ProviderTypes returns the names of all registered provider types
+func ProviderTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for name := range registry {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// providerRegistered reports whether a provider type has been registered
+func providerRegistered(providerType string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registry[providerType]
+	return ok
+}
+
+// newProvider builds a provider of the given registered type
+func newProvider(providerType string, cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+	registryMu.RLock()
+	factory, ok := registry[providerType]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown provider type: %s", providerType)
+	}
+	return factory(cfg, httpClient)
+}
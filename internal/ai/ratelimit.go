@@ -0,0 +1,188 @@
+package ai
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"axon/internal/config"
+	"axon/internal/logger"
+)
+
+// QueueStatus describes the requests held back by rate limits
+type QueueStatus struct {
+	// Queued is the number of requests waiting for a token or an in-flight slot
+	Queued int
+	// Wait is the longest time a queued request still has to wait for a
+	// token. It is zero when requests only wait for in-flight ones to finish.
+	Wait time.Duration
+}
+
+// rateLimiter throttles the requests sent to the models matching one
+// rate_limits entry. Requests queue for a token from a token bucket that
+// refills at the configured rate, and for one of a fixed number of
+// in-flight slots.
+type rateLimiter struct {
+	mu sync.Mutex
+	// tokens may go negative: each waiting request holds a reservation
+	tokens   float64
+	capacity float64
+	// perSecond is the refill rate; zero disables the token bucket
+	perSecond float64
+	updated   time.Time
+	// slots bounds in-flight requests; nil allows any number
+	slots chan struct{}
+	// waiting maps each queued request to the time its token is ready
+	waiting    map[int]time.Time
+	nextTicket int
+	now        func() time.Time
+}
+
+// newRateLimiter creates a limiter from configuration
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	burst := float64(max(cfg.Burst, 1))
+	l := &rateLimiter{
+		tokens:    burst,
+		capacity:  burst,
+		perSecond: cfg.RequestsPerMinute / 60,
+		waiting:   make(map[int]time.Time),
+		now:       time.Now,
+	}
+	l.updated = l.now()
+	if cfg.MaxInFlight > 0 {
+		l.slots = make(chan struct{}, cfg.MaxInFlight)
+	}
+	return l
+}
+
+// acquire waits until a request may be sent, returning a function that
+// releases its in-flight slot. It fails only when ctx ends first.
+func (l *rateLimiter) acquire(ctx context.Context) (func(), error) {
+	ticket := l.enqueue()
+	defer l.dequeue(ticket)
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	wait := l.reserve()
+	if wait <= 0 {
+		return release, nil
+	}
+
+	l.setReadyAt(ticket, l.now().Add(wait))
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return release, nil
+	case <-ctx.Done():
+		l.cancelReservation()
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// reserve takes a token from the bucket, returning how long until it is available
+func (l *rateLimiter) reserve() time.Duration {
+	if l.perSecond <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(-l.tokens / l.perSecond * float64(time.Second)))
+}
+
+// cancelReservation returns the token of a request that gave up waiting
+func (l *rateLimiter) cancelReservation() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens = min(l.tokens+1, l.capacity)
+}
+
+// refill adds the tokens accrued since the last update; callers hold mu
+func (l *rateLimiter) refill() {
+	now := l.now()
+	l.tokens = min(l.tokens+now.Sub(l.updated).Seconds()*l.perSecond, l.capacity)
+	l.updated = now
+}
+
+// enqueue records a queued request whose token time is not yet known
+func (l *rateLimiter) enqueue() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextTicket++
+	l.waiting[l.nextTicket] = time.Time{}
+	return l.nextTicket
+}
+
+// setReadyAt records when a queued request's token is available
+func (l *rateLimiter) setReadyAt(ticket int, readyAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiting[ticket] = readyAt
+}
+
+// dequeue forgets a request once it has been sent or given up
+func (l *rateLimiter) dequeue(ticket int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.waiting, ticket)
+}
+
+// status reports the requests queued on this limiter
+func (l *rateLimiter) status() QueueStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := QueueStatus{Queued: len(l.waiting)}
+	now := l.now()
+	for _, readyAt := range l.waiting {
+		if wait := readyAt.Sub(now); !readyAt.IsZero() && wait > status.Wait {
+			status.Wait = wait
+		}
+	}
+	return status
+}
+
+// acquireSlot waits for the rate limit covering a model, if any. A limit
+// keyed by a glob pattern is shared by every model the pattern matches.
+func (c *Client) acquireSlot(ctx context.Context, model string) (func(), error) {
+	key, ok := matchKey(c.rateLimiters, model)
+	if !ok {
+		return func() {}, nil
+	}
+	limiter := c.rateLimiters[key]
+	if status := limiter.status(); status.Queued > 0 {
+		logger.Info("Queueing request for %s behind %d others (rate limit %s)", model, status.Queued, key)
+	}
+	return limiter.acquire(ctx)
+}
+
+// QueueStatus reports the requests currently held back by rate limits
+func (c *Client) QueueStatus() QueueStatus {
+	var total QueueStatus
+	for _, limiter := range c.rateLimiters {
+		status := limiter.status()
+		total.Queued += status.Queued
+		total.Wait = max(total.Wait, status.Wait)
+	}
+	return total
+}
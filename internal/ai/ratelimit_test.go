@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"axon/internal/config"
+)
+
+func TestRateLimiterReservations(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(config.RateLimitConfig{RequestsPerMinute: 60, Burst: 2})
+	limiter.now = func() time.Time { return now }
+	limiter.updated = now
+
+	for i := 0; i < 2; i++ {
+		if wait := limiter.reserve(); wait != 0 {
+			t.Fatalf("Expected burst request %d to go straight through, waited %v", i, wait)
+		}
+	}
+	if wait := limiter.reserve(); wait != time.Second {
+		t.Errorf("Expected third request to wait 1s at 60 rpm, got %v", wait)
+	}
+	if wait := limiter.reserve(); wait != 2*time.Second {
+		t.Errorf("Expected fourth request to queue behind the third, got %v", wait)
+	}
+
+	// Returned tokens and elapsed time shorten later waits
+	limiter.cancelReservation()
+	now = now.Add(1500 * time.Millisecond)
+	if wait := limiter.reserve(); wait != 500*time.Millisecond {
+		t.Errorf("Expected 500ms wait after refill, got %v", wait)
+	}
+}
+
+func TestRateLimiterMaxInFlight(t *testing.T) {
+	limiter := newRateLimiter(config.RateLimitConfig{MaxInFlight: 1})
+
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		next, err := limiter.acquire(context.Background())
+		if err == nil {
+			next()
+		}
+		close(acquired)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for limiter.status().Queued == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if status := limiter.status(); status.Queued != 1 || status.Wait != 0 {
+		t.Errorf("Expected one request queued for a slot, got %+v", status)
+	}
+
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Queued request never got the released slot")
+	}
+
+	// A request that gives up waiting reports its context error
+	release, _ = limiter.acquire(context.Background())
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestClientRateLimitQueues(t *testing.T) {
+	provider := &fakeProvider{reply: "ok"}
+	RegisterProvider("test_rate_limit", func(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+		return provider, nil
+	})
+	client := NewClientFromConfig(config.AIConfig{
+		DefaultProvider: "fake",
+		Providers: map[string]config.ProviderConfig{
+			"fake": {Type: "test_rate_limit"},
+		},
+		// 1200 rpm refills a token every 50ms
+		RateLimits: map[string]config.RateLimitConfig{
+			"*/*:free": {RequestsPerMinute: 1200},
+		},
+	})
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Generate(context.Background(), Request{Prompt: "p", Model: "any/model:free"}); err != nil {
+				t.Errorf("Expected queued request to succeed, got %v", err)
+			}
+		}()
+	}
+
+	sawWait := false
+	for i := 0; i < 20 && !sawWait; i++ {
+		sawWait = client.QueueStatus().Wait > 0
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected the shared limit to space out three requests, took %v", elapsed)
+	}
+	if !sawWait {
+		t.Error("Expected QueueStatus to report the wait")
+	}
+	if status := client.QueueStatus(); status.Queued != 0 {
+		t.Errorf("Expected an empty queue afterwards, got %+v", status)
+	}
+
+	// Models outside the limit are not throttled
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		_, _ = client.Generate(context.Background(), Request{Prompt: "p", Model: "paid/model"})
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("Expected unlimited model to go straight through, took %v", elapsed)
+	}
+}
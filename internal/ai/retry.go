@@ -0,0 +1,176 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"axon/internal/config"
+	"axon/internal/logger"
+)
+
+// retryPolicy decides whether and when a failed request is tried again
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	jitter         float64
+}
+
+// newRetryPolicy converts configuration into a policy. A zero configuration
+// means a single attempt with no retries.
+func newRetryPolicy(cfg config.RetryConfig) retryPolicy {
+	policy := retryPolicy{
+		maxAttempts:    cfg.MaxAttempts,
+		initialBackoff: time.Duration(cfg.InitialBackoffMs) * time.Millisecond,
+		maxBackoff:     time.Duration(cfg.MaxBackoffMs) * time.Millisecond,
+		jitter:         cfg.Jitter,
+	}
+	if policy.maxAttempts < 1 {
+		policy.maxAttempts = 1
+	}
+	if policy.maxBackoff < policy.initialBackoff {
+		policy.maxBackoff = policy.initialBackoff
+	}
+	if policy.jitter < 0 || policy.jitter > 1 {
+		policy.jitter = 0
+	}
+	return policy
+}
+
+// backoff returns the delay before the given retry (1 for the first retry)
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.initialBackoff
+	for i := 1; i < retry && delay < p.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	if p.jitter > 0 && delay > 0 {
+		spread := float64(delay) * p.jitter
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+	return delay
+}
+
+// isRetryable reports whether an error is transient: rate limits, server
+// errors and network failures. Cancellation and client errors are final.
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryAfter returns the server-requested delay carried by an error, if any
+func retryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// attemptFunc performs a single request against a resolved provider
+type attemptFunc func(ctx context.Context, provider Provider, req Request) (*Response, error)
+
+// failoverChain lists the models to try for a request: the requested model
+// followed by the configured failover models for its task
+func (c *Client) failoverChain(req Request) []string {
+	chain := []string{req.Model}
+	seen := map[string]bool{req.Model: true}
+	for _, model := range c.failover[req.Task] {
+		if !seen[model] {
+			seen[model] = true
+			chain = append(chain, model)
+		}
+	}
+	return chain
+}
+
+// generateWithRetry runs attempt against each model in the failover chain,
+// retrying transient failures with exponential backoff. Without a deadline on
+// ctx each attempt gets the default timeout, and an attempt that runs out of
+// it is retried like any other transient failure. A response that already
+// carries partial text is never retried, so streamed output is not repeated.
+// The returned response is never nil.
+func (c *Client) generateWithRetry(ctx context.Context, req Request, attempt attemptFunc) (*Response, error) {
+	last := &Response{Model: req.Model}
+	var lastErr error
+	for _, model := range c.failoverChain(req) {
+		modelReq := req
+		modelReq.Model = model
+
+		name, provider := c.providerFor(model)
+		if provider == nil {
+			logger.Error("No AI provider available for model %s", model)
+			last, lastErr = &Response{Model: model}, fmt.Errorf("no provider configured for model %s", model)
+			continue
+		}
+
+		for try := 1; try <= c.retry.maxAttempts; try++ {
+			logger.Debug("AI attempt %d/%d for %s via %s", try, c.retry.maxAttempts, model, name)
+			release, err := c.acquireSlot(ctx, model)
+			if err != nil {
+				logger.Info("Gave up waiting for the %s rate limit: %v", model, err)
+				if lastErr == nil {
+					last, lastErr = &Response{Model: model}, err
+				}
+				return last, timeoutError(lastErr)
+			}
+			attemptCtx, cancel := c.withDefaultTimeout(ctx)
+			resp, err := attempt(attemptCtx, provider, modelReq)
+			timedOut := attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+			cancel()
+			release()
+			if resp == nil {
+				resp = &Response{}
+			}
+			resp.Model = model
+			if err == nil || resp.Text != "" {
+				return resp, timeoutError(err)
+			}
+
+			last, lastErr = resp, err
+			logger.Error("AI attempt %d/%d for %s via %s failed: %v", try, c.retry.maxAttempts, model, name, err)
+
+			if ctx.Err() != nil || !(isRetryable(err) || timedOut) || try == c.retry.maxAttempts {
+				break
+			}
+
+			wait := c.retry.backoff(try)
+			if requested := retryAfter(err); requested > 0 {
+				if requested > c.retry.maxBackoff {
+					logger.Info("%s asked to wait %v, moving on", name, requested)
+					break
+				}
+				wait = requested
+			}
+
+			logger.Info("Retrying %s in %v", model, wait)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return last, timeoutError(lastErr)
+			}
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return last, timeoutError(lastErr)
+}
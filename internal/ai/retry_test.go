@@ -0,0 +1,171 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"axon/internal/config"
+)
+
+// fastRetry keeps test backoffs short
+var fastRetry = config.RetryConfig{MaxAttempts: 3, InitialBackoffMs: 1, MaxBackoffMs: 5}
+
+func TestRetryOnRateLimit(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":"slow down"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"third time lucky"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientFromConfig(config.AIConfig{
+		OpenRouterAPIKey: "k",
+		Providers:        map[string]config.ProviderConfig{"openrouter": {BaseURL: server.URL}},
+		Retry:            fastRetry,
+	})
+
+	resp, err := client.Generate(context.Background(), Request{Prompt: "p", Model: "m"})
+	if err != nil {
+		t.Fatalf("Generate should not return error, got %v", err)
+	}
+	if resp.Text != "third time lucky" {
+		t.Errorf("Expected success after retries, got %q", resp.Text)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestFailoverToNextModel(t *testing.T) {
+	var primaryCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Model == "primary" {
+			atomic.AddInt32(&primaryCalls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"from backup"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientFromConfig(config.AIConfig{
+		OpenRouterAPIKey: "k",
+		Providers:        map[string]config.ProviderConfig{"openrouter": {BaseURL: server.URL}},
+		Retry:            fastRetry,
+		Failover:         map[string][]string{"storytelling": {"backup"}},
+	})
+
+	resp, err := client.Generate(context.Background(), Request{Prompt: "p", Model: "primary", Task: "storytelling"})
+	if err != nil {
+		t.Fatalf("Generate should not return error, got %v", err)
+	}
+	if resp.Text != "from backup" || resp.Model != "backup" {
+		t.Errorf("Expected backup model response, got %q from %s", resp.Text, resp.Model)
+	}
+	if primaryCalls != 3 {
+		t.Errorf("Expected primary to be tried 3 times, got %d", primaryCalls)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClientFromConfig(config.AIConfig{
+		OpenRouterAPIKey: "k",
+		Providers:        map[string]config.ProviderConfig{"openrouter": {BaseURL: server.URL}},
+		Retry:            fastRetry,
+	})
+
+	_, err := client.Generate(context.Background(), Request{Prompt: "p", Model: "m"})
+	if err == nil {
+		t.Error("Expected error for bad request")
+	}
+	if calls != 1 {
+		t.Errorf("Client errors should not be retried, got %d attempts", calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := newRetryPolicy(config.RetryConfig{MaxAttempts: 5, InitialBackoffMs: 100, MaxBackoffMs: 350})
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 350 * time.Millisecond}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("Retry %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+
+	jittered := newRetryPolicy(config.RetryConfig{MaxAttempts: 2, InitialBackoffMs: 100, MaxBackoffMs: 100, Jitter: 0.5})
+	for i := 0; i < 20; i++ {
+		if got := jittered.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("Jittered backoff %v outside expected range", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("3"); got != 3*time.Second {
+		t.Errorf("Expected 3s, got %v", got)
+	}
+	if got := parseRetryAfter(""); got != 0 {
+		t.Errorf("Expected 0 for missing header, got %v", got)
+	}
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got <= 0 || got > 10*time.Second {
+		t.Errorf("Expected HTTP date to parse to a positive delay, got %v", got)
+	}
+}
+
+// slowOnceProvider blocks its first request until the context ends, then answers
+type slowOnceProvider struct {
+	calls int32
+}
+
+func (p *slowOnceProvider) Generate(ctx context.Context, req Request) (*Response, error) {
+	if atomic.AddInt32(&p.calls, 1) == 1 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &Response{Text: "second wind"}, nil
+}
+
+func TestDefaultTimeoutPerAttempt(t *testing.T) {
+	provider := &slowOnceProvider{}
+	RegisterProvider("test_slow_once", func(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+		return provider, nil
+	})
+	client := NewClientFromConfig(config.AIConfig{
+		DefaultProvider: "slow",
+		Providers:       map[string]config.ProviderConfig{"slow": {Type: "test_slow_once"}},
+		Retry:           fastRetry,
+	})
+	client.requestTimeout = 20 * time.Millisecond
+
+	// One slow attempt uses up its own timeout, not the whole request's
+	resp, err := client.Generate(context.Background(), Request{Prompt: "p", Model: "m"})
+	if err != nil || resp.Text != "second wind" {
+		t.Fatalf("Expected the retry to succeed, got %q / %v", resp.Text, err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d", provider.calls)
+	}
+}
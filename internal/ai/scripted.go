@@ -0,0 +1,138 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"axon/internal/config"
+	"axon/internal/logger"
+)
+
+func init() {
+	RegisterProvider("scripted", newScriptedProvider)
+}
+
+// scriptFile is the fixture format replayed by the scripted provider
+type scriptFile struct {
+	Responses []scriptRule `json:"responses"`
+}
+
+// scriptRule answers requests whose task and prompt match. Task and Match
+// are optional; an empty value matches anything. Replies are returned in
+// order and the last one repeats; Text is shorthand for a single reply and
+// JSON for a single reply written as a JSON object rather than a string.
+type scriptRule struct {
+	Task    string          `json:"task,omitempty"`
+	Match   string          `json:"match,omitempty"`
+	Text    string          `json:"text,omitempty"`
+	JSON    json.RawMessage `json:"json,omitempty"`
+	Replies []string        `json:"replies,omitempty"`
+	// Error and Status simulate a failed request instead of a reply
+	Error  string `json:"error,omitempty"`
+	Status int    `json:"status,omitempty"`
+
+	pattern *regexp.Regexp
+	served  int
+}
+
+// scriptedProvider replays canned responses from a fixture file, so whole
+// sessions can run deterministically without a network
+type scriptedProvider struct {
+	mu    sync.Mutex
+	rules []*scriptRule
+}
+
+// newScriptedProvider loads the fixture named by the provider configuration
+func newScriptedProvider(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+	if cfg.Fixture == "" {
+		return nil, fmt.Errorf("scripted provider requires a fixture file")
+	}
+	data, err := os.ReadFile(cfg.Fixture)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	return parseScript(data)
+}
+
+// parseScript builds a scripted provider from fixture JSON
+func parseScript(data []byte) (*scriptedProvider, error) {
+	var file scriptFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("malformed fixture: %w", err)
+	}
+
+	provider := &scriptedProvider{}
+	for i := range file.Responses {
+		rule := &file.Responses[i]
+		if rule.Match != "" {
+			pattern, err := regexp.Compile(rule.Match)
+			if err != nil {
+				return nil, fmt.Errorf("fixture response %d: %w", i, err)
+			}
+			rule.pattern = pattern
+		}
+		if len(rule.JSON) > 0 {
+			rule.Replies = append([]string{string(rule.JSON)}, rule.Replies...)
+		}
+		if rule.Text != "" {
+			rule.Replies = append([]string{rule.Text}, rule.Replies...)
+		}
+		if len(rule.Replies) == 0 && rule.Error == "" {
+			return nil, fmt.Errorf("fixture response %d has neither text nor error", i)
+		}
+		provider.rules = append(provider.rules, rule)
+	}
+	return provider, nil
+}
+
+// Generate returns the next reply of the first rule matching the request
+func (p *scriptedProvider) Generate(ctx context.Context, req Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, rule := range p.rules {
+		if rule.Task != "" && rule.Task != req.Task {
+			continue
+		}
+		if rule.pattern != nil && !rule.pattern.MatchString(req.Prompt) {
+			continue
+		}
+
+		if rule.Error != "" {
+			logger.Debug("Scripted error for %s: %s", req.Task, rule.Error)
+			return nil, &APIError{Provider: "scripted", StatusCode: rule.Status, Message: rule.Error}
+		}
+
+		reply := rule.Replies[min(rule.served, len(rule.Replies)-1)]
+		rule.served++
+		logger.Debug("Scripted reply %d for %s", rule.served, req.Task)
+		return &Response{Text: reply}, nil
+	}
+
+	logger.Error("No scripted response for task %s and prompt %q", req.Task, req.Prompt)
+	return nil, fmt.Errorf("no scripted response for task %s", req.Task)
+}
+
+// GenerateStream replays the reply word by word, exercising streaming callers
+func (p *scriptedProvider) GenerateStream(ctx context.Context, req Request, onDelta StreamHandler) (*Response, error) {
+	resp, err := p.Generate(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	for _, word := range strings.SplitAfter(resp.Text, " ") {
+		if word != "" {
+			onDelta(word)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"axon/internal/config"
+)
+
+const testScript = `{
+  "responses": [
+    {"task": "dialog", "match": "(?i)hello", "replies": ["Hi there", "Hello again"]},
+    {"task": "dialog", "status": 503, "error": "overloaded"},
+    {"task": "world_building", "json": {"name": "Testland"}},
+    {"text": "Anything else"}
+  ]
+}`
+
+func TestScriptedProviderReplays(t *testing.T) {
+	provider, err := parseScript([]byte(testScript))
+	if err != nil {
+		t.Fatalf("parseScript returned error: %v", err)
+	}
+
+	tests := []struct {
+		req  Request
+		want string
+	}{
+		{Request{Task: "dialog", Prompt: "say HELLO"}, "Hi there"},
+		{Request{Task: "dialog", Prompt: "hello?"}, "Hello again"},
+		{Request{Task: "dialog", Prompt: "hello!"}, "Hello again"},
+		{Request{Task: "world_building", Prompt: "a world"}, `{"name": "Testland"}`},
+		{Request{Task: "storytelling", Prompt: "look"}, "Anything else"},
+	}
+	for _, test := range tests {
+		resp, err := provider.Generate(context.Background(), test.req)
+		if err != nil {
+			t.Fatalf("Unexpected error for %+v: %v", test.req, err)
+		}
+		if resp.Text != test.want {
+			t.Errorf("For %+v expected %q, got %q", test.req, test.want, resp.Text)
+		}
+	}
+
+	_, err = provider.Generate(context.Background(), Request{Task: "dialog", Prompt: "goodbye"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 503 {
+		t.Errorf("Expected scripted 503 error, got %v", err)
+	}
+}
+
+func TestScriptedProviderStreams(t *testing.T) {
+	provider, err := parseScript([]byte(`{"responses": [{"text": "one two three"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deltas []string
+	_, err = provider.GenerateStream(context.Background(), Request{}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(deltas) != 3 || strings.Join(deltas, "") != "one two three" {
+		t.Errorf("Expected word-by-word deltas, got %q", deltas)
+	}
+}
+
+func TestScriptedProviderNoMatch(t *testing.T) {
+	provider, err := parseScript([]byte(`{"responses": [{"task": "dialog", "text": "hi"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = provider.Generate(context.Background(), Request{Task: "storytelling"})
+	if err == nil || !strings.Contains(err.Error(), "no scripted response") {
+		t.Errorf("Expected no-match error, got %v", err)
+	}
+}
+
+func TestScriptedProviderInvalidFixtures(t *testing.T) {
+	for name, script := range map[string]string{
+		"malformed": `{"responses": [`,
+		"bad regex": `{"responses": [{"match": "(", "text": "x"}]}`,
+		"no reply":  `{"responses": [{"task": "dialog"}]}`,
+	} {
+		if _, err := parseScript([]byte(script)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if _, err := newScriptedProvider(config.ProviderConfig{}, nil); err == nil {
+		t.Error("Expected error without a fixture")
+	}
+}
+
+func TestScriptedProviderFromConfig(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "script.json")
+	if err := os.WriteFile(fixture, []byte(testScript), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClientFromConfig(config.AIConfig{
+		DefaultProvider: "script",
+		Providers: map[string]config.ProviderConfig{
+			"script": {Type: "scripted", Fixture: fixture},
+		},
+	})
+
+	resp, err := client.Generate(context.Background(), Request{Task: "storytelling", Model: "any/model"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Text != "Anything else" {
+		t.Errorf("Expected scripted reply, got %q", resp.Text)
+	}
+}
@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+
+	"axon/internal/logger"
+)
+
+// StreamHandler receives incremental pieces of generated text as they arrive
+type StreamHandler func(delta string)
+
+// StreamingProvider is implemented by providers that can deliver tokens as they are generated
+type StreamingProvider interface {
+	Provider
+	// GenerateStream calls onDelta for each piece of text and returns the
+	// accumulated response. If the stream breaks off, the returned response
+	// carries the partial text alongside the error.
+	GenerateStream(ctx context.Context, req Request, onDelta StreamHandler) (*Response, error)
+}
+
+// GenerateStream generates content, delivering text through onDelta as it
+// arrives. Providers without streaming support deliver the whole reply as a
+// single delta once it is complete.
+func (c *Client) GenerateStream(ctx context.Context, req Request, onDelta StreamHandler) (*Response, error) {
+	logger.Info("Starting streamed AI generation with model: %s", req.Model)
+	logger.LogRequest(req)
+
+	if onDelta == nil {
+		onDelta = func(string) {}
+	}
+
+	if resp, ok := c.cacheLookup(req); ok {
+		onDelta(resp.Text)
+		return resp, nil
+	}
+
+	resp, err := c.generateWithRetry(ctx, req, func(ctx context.Context, provider Provider, req Request) (*Response, error) {
+		streamer, ok := provider.(StreamingProvider)
+		if !ok {
+			logger.Debug("Provider for %s does not stream, falling back to a single response", req.Model)
+			resp, err := provider.Generate(ctx, req)
+			if err == nil && resp.Text != "" {
+				onDelta(resp.Text)
+			}
+			return resp, err
+		}
+		return streamer.GenerateStream(ctx, req, onDelta)
+	})
+	if err != nil {
+		logger.Error("AI stream failed: %v", err)
+	} else {
+		logger.Info("AI stream completed")
+		logger.LogResponse(resp)
+		c.cacheStore(req, resp)
+	}
+
+	return resp, err
+}
+
+// readSSE reads a server-sent event stream, calling onData with the payload
+// of each "data:" line. Reading stops when onData reports done, the stream
+// ends, or an error occurs.
+func readSSE(r io.Reader, onData func(data string) (done bool, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Blank lines separate events; lines starting with ':' are keep-alive comments
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		done, err := onData(data)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,89 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"axon/internal/config"
+)
+
+func TestOpenRouterStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(": OPENROUTER PROCESSING\n\n" +
+			`data: {"choices":[{"delta":{"content":"The door "}}]}` + "\n\n" +
+			`data: {"choices":[{"delta":{"content":"creaks open."}}]}` + "\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewClientFromConfig(config.AIConfig{
+		OpenRouterAPIKey: "test_key",
+		Providers: map[string]config.ProviderConfig{
+			"openrouter": {BaseURL: server.URL},
+		},
+	})
+
+	var deltas []string
+	resp, err := client.GenerateStream(context.Background(), Request{Prompt: "open door", Model: "any/model"}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream should not return error, got %v", err)
+	}
+	if resp.Text != "The door creaks open." {
+		t.Errorf("Unexpected text %q", resp.Text)
+	}
+	if len(deltas) != 2 {
+		t.Errorf("Expected 2 deltas, got %d: %v", len(deltas), deltas)
+	}
+}
+
+func TestOpenRouterStreamInterrupted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`data: {"choices":[{"delta":{"content":"Half a sen"}}]}` + "\n\n" +
+			`data: {"error":{"message":"upstream disconnected"}}` + "\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := newOpenRouterProvider(config.ProviderConfig{APIKey: "k", BaseURL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := provider.(StreamingProvider).GenerateStream(context.Background(), Request{Prompt: "p", Model: "m"}, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "upstream disconnected") {
+		t.Errorf("Expected stream error, got %v", err)
+	}
+	if resp.Text != "Half a sen" {
+		t.Errorf("Expected partial text to be kept, got %q", resp.Text)
+	}
+}
+
+func TestGenerateStreamFallback(t *testing.T) {
+	fake := &fakeProvider{reply: "whole reply"}
+	RegisterProvider("test_nostream", func(cfg config.ProviderConfig, httpClient *http.Client) (Provider, error) {
+		return fake, nil
+	})
+
+	client := NewClientFromConfig(config.AIConfig{
+		DefaultProvider: "plain",
+		Providers: map[string]config.ProviderConfig{
+			"plain": {Type: "test_nostream"},
+		},
+	})
+
+	var deltas []string
+	resp, err := client.GenerateStream(context.Background(), Request{Prompt: "p", Model: "m"}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream should not return error, got %v", err)
+	}
+	if resp.Text != "whole reply" || len(deltas) != 1 || deltas[0] != "whole reply" {
+		t.Errorf("Expected a single delta with the whole reply, got %v", deltas)
+	}
+}
@@ -26,6 +26,8 @@ type TerminalConfig struct {
 	AutoDetect       bool `json:"auto_detect"`
 	MouseEnabled     bool `json:"mouse_enabled"`
 	AltScreenEnabled bool `json:"alt_screen_enabled"`
+	// MapPanel shows a map of visited locations beside the game history; Tab toggles it in play
+	MapPanel bool `json:"map_panel"`
 }
 
 // AIConfig contains AI model settings
@@ -33,12 +35,100 @@ type AIConfig struct {
 	OpenRouterAPIKey string `json:"openrouter_api_key"`
 	GeminiAPIKey     string `json:"gemini_api_key"`
 	DefaultModel     string `json:"default_model"`
+	// DefaultProvider handles models that match no model_providers entry
+	DefaultProvider string `json:"default_provider"`
+	// Providers configures named provider instances, keyed by provider name
+	Providers map[string]ProviderConfig `json:"providers,omitempty"`
+	// ModelProviders maps model names or glob patterns (e.g. "google/*") to provider names
+	ModelProviders map[string]string `json:"model_providers,omitempty"`
+	// TaskTimeouts sets a deadline in seconds for each AI task (world_building, storytelling, ...)
+	TaskTimeouts map[string]int `json:"task_timeouts,omitempty"`
+	// Retry controls how transient failures are retried
+	Retry RetryConfig `json:"retry"`
+	// Failover lists, per task, the models to try in order once the primary model gives up
+	Failover map[string][]string `json:"failover,omitempty"`
+	// Tasks overrides the model and generation settings per AI task
+	Tasks map[string]TaskConfig `json:"tasks,omitempty"`
+	// ContextTokens caps the prompt context sent to a model, keyed by model name or glob pattern
+	ContextTokens map[string]int `json:"context_tokens,omitempty"`
+	// Pricing sets the token prices of models, keyed by model name or glob pattern
+	Pricing map[string]ModelPrice `json:"pricing,omitempty"`
+	// Cache stores completions so identical requests are not paid for twice
+	Cache CacheConfig `json:"cache"`
+	// RateLimits throttles requests, keyed by model name or glob pattern. A
+	// pattern's limit is shared by every model it matches.
+	RateLimits map[string]RateLimitConfig `json:"rate_limits,omitempty"`
+}
+
+// RateLimitConfig limits how fast and how many requests are sent to a model
+type RateLimitConfig struct {
+	// RequestsPerMinute is the sustained request rate; zero means unlimited
+	RequestsPerMinute float64 `json:"requests_per_minute"`
+	// Burst is how many requests may be sent at once after a quiet spell; defaults to 1
+	Burst int `json:"burst,omitempty"`
+	// MaxInFlight caps concurrent requests; zero means unlimited
+	MaxInFlight int `json:"max_in_flight,omitempty"`
+}
+
+// CacheConfig contains settings for the AI response cache
+type CacheConfig struct {
+	Enabled bool `json:"enabled"`
+	// Dir holds cached responses on disk; empty keeps them in memory only
+	Dir string `json:"dir"`
+	// TTLSeconds is how long a response stays valid; zero never expires
+	TTLSeconds int `json:"ttl_seconds"`
+	// MaxEntries caps the responses kept in memory
+	MaxEntries int `json:"max_entries"`
+	// MaxDiskEntries caps the responses kept in Dir
+	MaxDiskEntries int `json:"max_disk_entries"`
+}
+
+// ModelPrice is the price of a model in US dollars per million tokens
+type ModelPrice struct {
+	PromptPerMillion     float64 `json:"prompt_per_million"`
+	CompletionPerMillion float64 `json:"completion_per_million"`
+}
+
+// RetryConfig contains the retry policy for failed AI requests
+type RetryConfig struct {
+	// MaxAttempts is the number of tries per model, including the first
+	MaxAttempts      int `json:"max_attempts"`
+	InitialBackoffMs int `json:"initial_backoff_ms"`
+	MaxBackoffMs     int `json:"max_backoff_ms"`
+	// Jitter randomizes each backoff by up to this fraction (0-1)
+	Jitter float64 `json:"jitter"`
+}
+
+// TaskConfig contains the model and generation settings for one AI task
+type TaskConfig struct {
+	// Model defaults to default_model when empty
+	Model string `json:"model,omitempty"`
+	// Temperature and TopP are left to the provider when unset
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	// MaxTokens defaults to the game's built-in limit for the task when zero
+	MaxTokens int `json:"max_tokens,omitempty"`
+}
+
+// ProviderConfig contains settings for a single AI provider
+type ProviderConfig struct {
+	// Type selects the registered provider implementation; defaults to the provider name
+	Type    string `json:"type,omitempty"`
+	APIKey  string `json:"api_key,omitempty"`
+	BaseURL string `json:"base_url,omitempty"`
+	// Fixture is the response script replayed by the scripted provider
+	Fixture string `json:"fixture,omitempty"`
 }
 
 // GameConfig contains game-specific settings
 type GameConfig struct {
 	HistoryLimit int    `json:"history_limit"`
 	SaveDir      string `json:"save_dir"`
+	// SkillChecks lets the rules model call for dice rolls against player
+	// stats, at the cost of one extra request before most actions
+	SkillChecks bool `json:"skill_checks"`
+	// DiceSeed makes skill check rolls repeatable; zero seeds them from the clock
+	DiceSeed int64 `json:"dice_seed"`
 }
 
 // Load loads configuration from file or creates default
@@ -78,6 +168,7 @@ func (c *Config) Save() error {
 func defaultConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
 	saveDir := filepath.Join(homeDir, ".axon", "saves")
+	cacheDir := filepath.Join(homeDir, ".axon", "cache")
 
 	return &Config{
 		Terminal: TerminalConfig{
@@ -94,14 +185,59 @@ func defaultConfig() *Config {
 			OpenRouterAPIKey: os.Getenv("OPENROUTER_API_KEY"),
 			GeminiAPIKey:     os.Getenv("GEMINI_API_KEY"),
 			DefaultModel:     "openai/gpt-4o-mini",
+			DefaultProvider:  "openrouter",
+			ModelProviders:   DefaultModelProviders(),
+			TaskTimeouts: map[string]int{
+				"world_building": 60,
+				"storytelling":   45,
+				"rule_setting":   20,
+				"dialog":         45,
+				"summarization":  30,
+			},
+			Tasks: map[string]TaskConfig{
+				"world_building": {MaxTokens: 800},
+				"storytelling":   {MaxTokens: 600},
+				"dialog":         {MaxTokens: 600},
+				"rule_setting":   {MaxTokens: 200},
+				"summarization":  {MaxTokens: 500},
+			},
+			Pricing: map[string]ModelPrice{
+				"openai/gpt-4o-mini": {PromptPerMillion: 0.15, CompletionPerMillion: 0.60},
+				"*/*:free":           {},
+			},
+			Retry: RetryConfig{
+				MaxAttempts:      3,
+				InitialBackoffMs: 500,
+				MaxBackoffMs:     8000,
+				Jitter:           0.2,
+			},
+			RateLimits: map[string]RateLimitConfig{
+				"*/*:free": {RequestsPerMinute: 20, Burst: 2, MaxInFlight: 2},
+			},
+			Cache: CacheConfig{
+				Dir:            cacheDir,
+				TTLSeconds:     86400,
+				MaxEntries:     256,
+				MaxDiskEntries: 4096,
+			},
 		},
 		Game: GameConfig{
 			HistoryLimit: 1000,
 			SaveDir:      saveDir,
+			SkillChecks:  false,
 		},
 	}
 }
 
+// DefaultModelProviders returns the built-in model routes, which send
+// "google/" models to Gemini and "local/" models to a local server
+func DefaultModelProviders() map[string]string {
+	return map[string]string{
+		"google/*": "gemini",
+		"local/*":  "local",
+	}
+}
+
 // getConfigPath returns the path to the configuration file
 func getConfigPath() string {
 	homeDir, _ := os.UserHomeDir()
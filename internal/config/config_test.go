@@ -33,10 +33,35 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("Expected default model 'openai/gpt-4o-mini', got %s", cfg.AI.DefaultModel)
 	}
 
+	for _, task := range []string{"world_building", "storytelling", "rule_setting", "dialog"} {
+		taskCfg, ok := cfg.AI.Tasks[task]
+		if !ok || taskCfg.MaxTokens == 0 {
+			t.Errorf("Expected a default token limit for task %s", task)
+		}
+		if taskCfg.Model != "" {
+			t.Errorf("Task %s should use the default model, got %s", task, taskCfg.Model)
+		}
+	}
+
+	if cfg.AI.Cache.Enabled {
+		t.Error("Expected the response cache to be off by default")
+	}
+	if filepath.Base(cfg.AI.Cache.Dir) != "cache" || cfg.AI.Cache.TTLSeconds <= 0 {
+		t.Errorf("Unexpected cache defaults %+v", cfg.AI.Cache)
+	}
+
 	// Test game config
 	if cfg.Game.HistoryLimit != 1000 {
 		t.Errorf("Expected history limit 1000, got %d", cfg.Game.HistoryLimit)
 	}
+
+	if cfg.Game.SkillChecks || cfg.Game.DiceSeed != 0 {
+		t.Errorf("Expected skill checks off with clock-seeded dice, got %v / %d", cfg.Game.SkillChecks, cfg.Game.DiceSeed)
+	}
+
+	if cfg.AI.ModelProviders["google/*"] != "gemini" || cfg.AI.ModelProviders["local/*"] != "local" {
+		t.Errorf("Expected default routes for google/ and local/ models, got %v", cfg.AI.ModelProviders)
+	}
 }
 
 func TestConfigSaveLoad(t *testing.T) {
@@ -0,0 +1,29 @@
+package game
+
+import (
+	"errors"
+
+	"axon/internal/ai"
+)
+
+// describeAIError turns an AI failure into a message telling the player what
+// went wrong and what to do about it
+func describeAIError(err error) string {
+	switch {
+	case errors.Is(err, ai.ErrAuth):
+		return "The AI provider rejected the API key. Set OPENROUTER_API_KEY or GEMINI_API_KEY, " +
+			"or fix the key in ~/.axon/config.json."
+	case errors.Is(err, ai.ErrQuota):
+		return "Your AI account is out of credits or quota. Top it up, or switch default_model to a free model."
+	case errors.Is(err, ai.ErrRateLimited):
+		return "The AI provider is rate limiting requests. Wait a moment before your next action."
+	case errors.Is(err, ai.ErrContentFiltered):
+		return "The AI provider's safety filter blocked that turn. Try describing your action differently."
+	case errors.Is(err, ai.ErrTimeout):
+		return "The AI took too long to answer. Try again, or raise task_timeouts in ~/.axon/config.json."
+	case errors.Is(err, ai.ErrMalformed):
+		return "The AI sent a reply the game could not use. Trying again usually helps."
+	default:
+		return "The AI could not be reached. Check your network connection and provider settings."
+	}
+}
@@ -0,0 +1,157 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"axon/internal/logger"
+)
+
+// spinnerFrames are plain ASCII so the spinner renders on every terminal type
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+// Every engine request is tagged with the model's requestID at launch time;
+// results carrying an older id belong to a cancelled request and are dropped.
+
+// narrationChunkMsg carries a piece of narration streamed from the engine
+type narrationChunkMsg struct {
+	id   int
+	text string
+	ch   <-chan tea.Msg
+}
+
+// actionResultMsg reports a processed player action along with the updated
+// state and the usage the action added to it. Built-in engine commands, such
+// as inventory, answer from the state without playing a turn.
+type actionResultMsg struct {
+	id      int
+	state   *GameState
+	spent   UsageStats
+	builtin bool
+	err     error
+}
+
+// turnReadyMsg reports that an action's narration is complete, carrying a
+// snapshot to generate suggestions from while the rest of the turn is applied
+type turnReadyMsg struct {
+	id    int
+	ctx   context.Context
+	state *GameState
+	ch    <-chan tea.Msg
+}
+
+// worldResultMsg reports a generated world along with the new game state
+type worldResultMsg struct {
+	id    int
+	state *GameState
+	err   error
+}
+
+// suggestionsMsg delivers freshly generated action suggestions. Usage is
+// merged into the game even when the suggestions arrive too late to show.
+type suggestionsMsg struct {
+	id          int
+	suggestions []string
+	usage       UsageStats
+}
+
+// memoryMsg delivers the story memory summarized from a snapshot of a game,
+// identified by when it was created, along with what summarizing cost
+type memoryMsg struct {
+	game   time.Time
+	memory Memory
+	usage  UsageStats
+}
+
+// spinnerTickMsg advances the loading spinner
+type spinnerTickMsg struct {
+	id int
+}
+
+// runAction returns a command that processes an action against a working copy
+// of the game state, streaming narration chunks, the narrated snapshot and the
+// final result into ch
+func (m Model) runAction(ctx context.Context, id int, state *GameState, action string, ch chan tea.Msg) tea.Cmd {
+	engine := m.engine
+	builtin := isEngineCommand(ParseCommand(action))
+	width := m.historyWidth()
+	return func() tea.Msg {
+		go func() {
+			defer close(ch)
+			var before UsageStats
+			before.Merge(state.Usage)
+			err := engine.ProcessPlayerActionWithHandlers(ctx, state, action, ActionHandlers{
+				Width: width,
+				Narration: func(delta string) {
+					ch <- narrationChunkMsg{id: id, text: delta, ch: ch}
+				},
+				Narrated: func(snapshot *GameState) {
+					ch <- turnReadyMsg{id: id, ctx: ctx, state: snapshot, ch: ch}
+				},
+			})
+			ch <- actionResultMsg{id: id, state: state, spent: state.Usage.since(before), builtin: builtin, err: err}
+		}()
+		return <-ch
+	}
+}
+
+// waitForActionMsg returns a command that waits for the next message from an in-flight action
+func waitForActionMsg(ch <-chan tea.Msg) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// runWorldSetup returns a command that builds a world into a fresh game state
+func (m Model) runWorldSetup(ctx context.Context, id int, state *GameState, seedPrompt string) tea.Cmd {
+	engine := m.engine
+	return func() tea.Msg {
+		logger.Info("Starting world initialization process")
+		err := engine.InitializeWorld(ctx, state, seedPrompt)
+		logger.Info("World initialization process completed")
+		return worldResultMsg{id: id, state: state, err: err}
+	}
+}
+
+// runSuggestions returns a command that generates action suggestions from a snapshot of the game state
+func (m Model) runSuggestions(ctx context.Context, id int, state *GameState) tea.Cmd {
+	engine := m.engine
+	// The snapshot starts with no usage so only what suggestions cost is sent back
+	state.Usage = UsageStats{}
+	return func() tea.Msg {
+		suggestions, err := engine.GenerateActionSuggestions(ctx, state)
+		if err != nil {
+			logger.Debug("Suggestion generation stopped: %v", err)
+			return suggestionsMsg{id: id, usage: state.Usage}
+		}
+		logger.Debug("Generated suggestions: %v", suggestions)
+		return suggestionsMsg{id: id, suggestions: suggestions, usage: state.Usage}
+	}
+}
+
+// runMemoryUpkeep returns a command that summarizes older history from a
+// snapshot of the game state without holding up play
+func (m Model) runMemoryUpkeep(ctx context.Context, state *GameState) tea.Cmd {
+	engine := m.engine
+	// The snapshot starts with no usage so only what summarizing costs is sent back
+	state.Usage = UsageStats{}
+	return func() tea.Msg {
+		// Failures are logged by the engine and retried after a later turn
+		_ = engine.UpdateMemory(ctx, state)
+		return memoryMsg{game: state.CreatedAt, memory: state.Memory, usage: state.Usage}
+	}
+}
+
+// tickSpinner schedules the next spinner frame for a request
+func tickSpinner(id int) tea.Cmd {
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg {
+		return spinnerTickMsg{id: id}
+	})
+}
@@ -0,0 +1,153 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"axon/internal/logger"
+)
+
+// Rough number of characters per token for English prose
+const charsPerToken = 4
+
+// estimateTokens approximates how many tokens a piece of text costs
+func estimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// contextParts are the pieces of a prompt context. Setup and Instructions are
+// always sent; History is fitted into whatever budget remains, newest first.
+type contextParts struct {
+	Setup        []string
+	History      []HistoryEntry
+	Instructions []string
+	// Format renders a history entry as a context line
+	Format func(HistoryEntry) string
+}
+
+// builtContext is a prompt context that fits a token budget
+type builtContext struct {
+	Lines  []string
+	Tokens int
+	// HistoryKept and HistoryDropped count the history entries sent and left out
+	HistoryKept    int
+	HistoryDropped int
+	// OverBudget is set when the required lines alone exceed the budget
+	OverBudget bool
+}
+
+// historyOmittedNote tells the model that older history was left out
+const historyOmittedNote = "(%d earlier entries omitted)"
+
+// buildContext assembles a prompt context within budget tokens. Setup and
+// instructions are never dropped, so the model always sees the world rules,
+// location and player; history fills the rest, most recent entries first.
+func buildContext(parts contextParts, budget int) builtContext {
+	var built builtContext
+
+	required := 0
+	for _, line := range parts.Setup {
+		required += estimateTokens(line)
+	}
+	for _, line := range parts.Instructions {
+		required += estimateTokens(line)
+	}
+	if required > budget {
+		built.OverBudget = true
+	}
+
+	// Reserve room for the omission note so adding it cannot break the budget
+	remaining := budget - required - estimateTokens(fmt.Sprintf(historyOmittedNote, len(parts.History)))
+
+	format := parts.Format
+	if format == nil {
+		format = formatHistoryEntry
+	}
+
+	// Entries the formatter skips are neither kept nor dropped
+	kept := make([]string, 0, len(parts.History))
+	full := false
+	for i := len(parts.History) - 1; i >= 0; i-- {
+		line := format(parts.History[i])
+		if line == "" {
+			continue
+		}
+		cost := estimateTokens(line)
+		if full || cost > remaining {
+			full = true
+			built.HistoryDropped++
+			continue
+		}
+		remaining -= cost
+		kept = append(kept, line)
+	}
+	built.HistoryKept = len(kept)
+
+	built.Lines = append(built.Lines, parts.Setup...)
+	if built.HistoryDropped > 0 {
+		built.Lines = append(built.Lines, fmt.Sprintf(historyOmittedNote, built.HistoryDropped))
+	}
+	for i := len(kept) - 1; i >= 0; i-- {
+		built.Lines = append(built.Lines, kept[i])
+	}
+	built.Lines = append(built.Lines, parts.Instructions...)
+
+	for _, line := range built.Lines {
+		built.Tokens += estimateTokens(line)
+	}
+	return built
+}
+
+// formatHistoryEntry renders a history entry as "type: content"
+func formatHistoryEntry(entry HistoryEntry) string {
+	return fmt.Sprintf("%s: %s", entry.Type, entry.Content)
+}
+
+// logContext reports how a prompt context was fitted to its budget
+func logContext(task string, budget int, built builtContext) {
+	if built.OverBudget {
+		logger.Error("%s context setup alone exceeds the %d token budget (%d tokens)", task, budget, built.Tokens)
+	}
+	if built.HistoryDropped > 0 {
+		logger.Info("%s context truncated: kept %d history entries, dropped %d (%d/%d tokens)",
+			task, built.HistoryKept, built.HistoryDropped, built.Tokens, budget)
+		return
+	}
+	logger.Debug("%s context: %d history entries (%d/%d tokens)", task, built.HistoryKept, built.Tokens, budget)
+}
+
+// worldSetupLines describes the world and player; these are always sent to the game master
+func worldSetupLines(state *GameState) []string {
+	lines := []string{
+		fmt.Sprintf("World: %s - %s", state.World.Name, state.World.Description),
+	}
+	if len(state.World.Rules) > 0 {
+		lines = append(lines, "World rules: "+strings.Join(state.World.Rules, "; "))
+	}
+	lines = append(lines, state.Memory.promptLines()...)
+	lines = append(lines, state.questPromptLines()...)
+	location := fmt.Sprintf("Current Location: %s", state.World.CurrentLocation)
+	exits := "Exits: none known"
+	if current := state.World.Current(); current != nil {
+		if current.Description != "" {
+			location += " - " + current.Description
+		}
+		exits = "Exits: " + current.describeExits()
+	}
+	lines = append(lines, location, exits)
+	if present := state.npcsAt(state.World.CurrentLocation); len(present) > 0 {
+		lines = append(lines, "Characters here: "+describeNPCs(present))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Player: %s - %s", state.Player.Name, state.Player.Description),
+		fmt.Sprintf("Inventory: %s", describeInventory(state.Player.Inventory)),
+		fmt.Sprintf("Stats: %s", describeStats(state.Player.Stats)),
+	)
+	if state.Player.Status != "" {
+		lines = append(lines, fmt.Sprintf("Status: %s", state.Player.Status))
+	}
+	return lines
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	if estimateTokens("") != 0 {
+		t.Error("Empty text should cost nothing")
+	}
+	if got := estimateTokens("abcdefghi"); got != 3 {
+		t.Errorf("Expected 3 tokens for 9 characters, got %d", got)
+	}
+}
+
+func TestBuildContextFitsBudget(t *testing.T) {
+	history := make([]HistoryEntry, 0, 50)
+	for i := 0; i < 50; i++ {
+		history = append(history, HistoryEntry{Type: entryTypeNarrator, Content: strings.Repeat("x", 76)})
+	}
+
+	parts := contextParts{
+		Setup:        []string{"World rules: magic is real"},
+		History:      history,
+		Instructions: []string{"Respond briefly."},
+	}
+	built := buildContext(parts, 300)
+
+	if built.Tokens > 300 {
+		t.Errorf("Context uses %d tokens, over the 300 budget", built.Tokens)
+	}
+	if built.HistoryKept == 0 || built.HistoryDropped == 0 || built.HistoryKept+built.HistoryDropped != 50 {
+		t.Errorf("Unexpected truncation report: kept %d dropped %d", built.HistoryKept, built.HistoryDropped)
+	}
+	if built.Lines[0] != "World rules: magic is real" || built.Lines[len(built.Lines)-1] != "Respond briefly." {
+		t.Errorf("Setup and instructions must always be present: %q", built.Lines)
+	}
+	if !strings.Contains(built.Lines[1], "earlier entries omitted") {
+		t.Errorf("Expected omission note after setup, got %q", built.Lines[1])
+	}
+}
+
+func TestBuildContextKeepsNewestHistory(t *testing.T) {
+	history := []HistoryEntry{
+		{Type: entryTypePlayer, Content: "oldest " + strings.Repeat("o", 200)},
+		{Type: entryTypePlayer, Content: "middle"},
+		{Type: entryTypeNarrator, Content: "newest"},
+	}
+	built := buildContext(contextParts{History: history}, 20)
+
+	want := []string{"(1 earlier entries omitted)", "player: middle", "narrator: newest"}
+	if strings.Join(built.Lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected newest entries in order, got %q", built.Lines)
+	}
+}
+
+func TestBuildContextOverBudgetSetup(t *testing.T) {
+	built := buildContext(contextParts{
+		Setup:   []string{strings.Repeat("rule ", 100)},
+		History: []HistoryEntry{{Type: entryTypePlayer, Content: "look"}},
+	}, 10)
+
+	if !built.OverBudget || built.HistoryKept != 0 || len(built.Lines) != 2 {
+		t.Errorf("Setup must be kept even over budget: %+v", built)
+	}
+}
+
+func TestProcessPlayerActionContextIncludesSetup(t *testing.T) {
+	engine, provider := newReplyEngine(t, "The wind howls.")
+	state := NewGameState()
+	state.World.Rules = []string{"Iron burns the fae"}
+	state.World.CurrentLocation = "Old Mill"
+	state.Player.Inventory = []Item{{Name: "iron nail", Quantity: 2}}
+	for i := 0; i < 400; i++ {
+		state.AddHistoryEntry(entryTypeNarrator, strings.Repeat("filler ", 20))
+	}
+
+	if err := engine.ProcessPlayerAction(context.Background(), state, "listen"); err != nil {
+		t.Fatalf("ProcessPlayerAction returned error: %v", err)
+	}
+
+	sent := strings.Join(provider.requests[0].Context, "\n")
+	for _, want := range []string{"Iron burns the fae", "Old Mill", "iron nail (x2)", "earlier entries omitted", stateMarker} {
+		if !strings.Contains(sent, want) {
+			t.Errorf("Context should contain %q", want)
+		}
+	}
+	budget := engine.aiClient.ContextBudget(provider.requests[0].Model)
+	if tokens := estimateTokens(strings.Join(provider.requests[0].Context, "")); tokens > budget {
+		t.Errorf("Context of %d tokens exceeds the default budget", tokens)
+	}
+}
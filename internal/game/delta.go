@@ -0,0 +1,267 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"axon/internal/ai"
+)
+
+// stateMarker separates the narration of a game-master reply from its state delta
+const stateMarker = "---STATE---"
+
+// stateDeltaPrompt tells the game master how to report the consequences of a turn
+const stateDeltaPrompt = `After the narration, write a line containing only ` + stateMarker + ` followed by a single JSON object describing what changed this turn:
+{
+  "items_gained": [{"name": "item", "description": "short description", "quantity": 1}],
+  "items_lost": [{"name": "item", "quantity": 1}],
+  "stat_changes": {"health": -2},
+  "location": "name of the new location, only if the player moved",
+  "location_description": "one or two sentences, only for a location the player has not visited",
+  "direction": "the direction the player went to get there, such as north or up, only if the player moved",
+  "unlock_exits": ["directions of exits from the current location that are no longer locked"],
+  "reveal_exits": ["directions of hidden exits from the current location that the player found"],
+  "quests": [{"title": "quest to open or update", "description": "one sentence, for new quests", "objectives": ["objectives to add"], "completed_objectives": ["objectives the player just achieved"], "status": "completed or failed, only when the quest ends", "rewards": [{"name": "item", "description": "short description", "quantity": 1}]}],
+  "npcs": [{"name": "a character the player met or who moved", "description": "one sentence, for new characters", "location": "where they are now, if not here"}],
+  "status": "new player status, only if it changed"
+}
+Omit fields that did not change. Use {} when nothing changed. Only remove items the player actually has.
+Open a quest when the player takes on a goal. Quest rewards are given automatically when the quest is completed, so do not also list them in items_gained.`
+
+// stateDelta is the machine-readable outcome of a turn
+type stateDelta struct {
+	ItemsGained         []Item         `json:"items_gained"`
+	ItemsLost           []Item         `json:"items_lost"`
+	StatChanges         map[string]int `json:"stat_changes"`
+	Location            string         `json:"location"`
+	LocationDescription string         `json:"location_description"`
+	Direction           string         `json:"direction"`
+	UnlockExits         []string       `json:"unlock_exits"`
+	RevealExits         []string       `json:"reveal_exits"`
+	NPCs                []npcSpec      `json:"npcs"`
+	Quests              []questUpdate  `json:"quests"`
+	Status              string         `json:"status"`
+}
+
+// splitTurnReply separates a game-master reply into its narration and the raw
+// state delta that follows the marker. The delta is nil when there is no marker.
+func splitTurnReply(text string) (string, []byte) {
+	idx := strings.Index(text, stateMarker)
+	if idx < 0 {
+		return text, nil
+	}
+	narration := strings.TrimSpace(text[:idx])
+	raw, err := extractJSONObject(text[idx+len(stateMarker):])
+	if err != nil {
+		return narration, nil
+	}
+	return narration, raw
+}
+
+// parseStateDelta decodes a state delta JSON object
+func parseStateDelta(raw []byte) (*stateDelta, error) {
+	var delta stateDelta
+	if err := json.Unmarshal(raw, &delta); err != nil {
+		return nil, fmt.Errorf("malformed state delta: %w", err)
+	}
+	delta.ItemsGained = normalizeItems(delta.ItemsGained)
+	delta.ItemsLost = normalizeItems(delta.ItemsLost)
+	delta.Location = strings.TrimSpace(delta.Location)
+	delta.LocationDescription = strings.TrimSpace(delta.LocationDescription)
+	delta.NPCs = normalizeNPCs(delta.NPCs)
+	delta.Quests = normalizeQuestUpdates(delta.Quests)
+	delta.Status = strings.TrimSpace(delta.Status)
+	return &delta, nil
+}
+
+// applyStateDelta applies the valid parts of a delta to the game state and
+// returns a description of each change that was made. Changes that do not
+// fit the current state, such as losing an item the player does not hold,
+// are skipped.
+func applyStateDelta(state *GameState, delta *stateDelta) []string {
+	var changes []string
+
+	for _, item := range delta.ItemsGained {
+		state.Player.addItem(item)
+		changes = append(changes, fmt.Sprintf("Gained: %s (x%d)", item.Name, item.Quantity))
+	}
+
+	for _, item := range delta.ItemsLost {
+		if removed := state.Player.removeItem(item.Name, item.Quantity); removed > 0 {
+			changes = append(changes, fmt.Sprintf("Lost: %s (x%d)", item.Name, removed))
+		}
+	}
+
+	stats := make([]string, 0, len(delta.StatChanges))
+	for stat := range delta.StatChanges {
+		stats = append(stats, stat)
+	}
+	sort.Strings(stats)
+	for _, stat := range stats {
+		change := delta.StatChanges[stat]
+		name := strings.TrimSpace(stat)
+		if name == "" || change == 0 {
+			continue
+		}
+		// Saves from before stats existed, or with "stats": null, load with no map
+		if state.Player.Stats == nil {
+			state.Player.Stats = make(map[string]int)
+		}
+		state.Player.Stats[name] += change
+		changes = append(changes, fmt.Sprintf("%s %+d (now %d)", name, change, state.Player.Stats[name]))
+	}
+
+	if delta.Location != "" && !strings.EqualFold(delta.Location, state.World.CurrentLocation) {
+		from := state.World.Current()
+		loc := state.World.addLocation(delta.Location, delta.LocationDescription)
+		if from != nil {
+			state.World.link(from, loc, canonicalDirection(delta.Direction))
+		}
+		state.World.moveTo(loc)
+		changes = append(changes, fmt.Sprintf("Location: %s", loc.Name))
+	}
+
+	if current := state.World.Current(); current != nil {
+		for _, direction := range delta.UnlockExits {
+			if exit := current.exit(canonicalDirection(direction)); exit != nil && exit.Locked {
+				exit.Locked = false
+				changes = append(changes, fmt.Sprintf("Unlocked: the way %s to %s", exit.Direction, exit.To))
+			}
+		}
+		for _, direction := range delta.RevealExits {
+			if exit := current.exit(canonicalDirection(direction)); exit != nil && exit.Hidden {
+				exit.Hidden = false
+				changes = append(changes, fmt.Sprintf("Discovered: a way %s to %s", exit.Direction, exit.To))
+			}
+		}
+	}
+
+	for _, spec := range delta.NPCs {
+		if npc, met := state.meetNPC(spec); met {
+			changes = append(changes, fmt.Sprintf("Met: %s", npc.Name))
+		}
+	}
+
+	for _, update := range delta.Quests {
+		changes = append(changes, state.applyQuestUpdate(update)...)
+	}
+
+	if delta.Status != "" && delta.Status != state.Player.Status {
+		state.Player.Status = delta.Status
+		changes = append(changes, fmt.Sprintf("Status: %s", delta.Status))
+	}
+
+	return changes
+}
+
+// describeInventory lists inventory items on one line for the game master
+func describeInventory(items []Item) string {
+	if len(items) == 0 {
+		return "empty"
+	}
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, fmt.Sprintf("%s (x%d)", item.Name, item.Quantity))
+	}
+	return strings.Join(names, ", ")
+}
+
+// describeStats lists player stats on one line for the game master
+func describeStats(stats map[string]int) string {
+	if len(stats) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(stats))
+	for stat := range stats {
+		names = append(names, stat)
+	}
+	sort.Strings(names)
+	for i, stat := range names {
+		names[i] = fmt.Sprintf("%s %d", stat, stats[stat])
+	}
+	return strings.Join(names, ", ")
+}
+
+// addItem adds an item to the inventory, stacking it with an item of the same name
+func (p *Player) addItem(item Item) {
+	for i := range p.Inventory {
+		if strings.EqualFold(p.Inventory[i].Name, item.Name) {
+			p.Inventory[i].Quantity += item.Quantity
+			return
+		}
+	}
+	p.Inventory = append(p.Inventory, item)
+}
+
+// removeItem removes up to quantity of the named item and returns how many were removed
+func (p *Player) removeItem(name string, quantity int) int {
+	for i := range p.Inventory {
+		if !strings.EqualFold(p.Inventory[i].Name, name) {
+			continue
+		}
+		if quantity >= p.Inventory[i].Quantity {
+			removed := p.Inventory[i].Quantity
+			p.Inventory = append(p.Inventory[:i], p.Inventory[i+1:]...)
+			return removed
+		}
+		p.Inventory[i].Quantity -= quantity
+		return quantity
+	}
+	return 0
+}
+
+// narrationFilter forwards streamed narration until the state marker appears,
+// holding back any trailing text that could be the start of a split marker
+type narrationFilter struct {
+	onNarration ai.StreamHandler
+	// onComplete, if set, receives the whole narration when the marker appears
+	onComplete func(narration string)
+	narration  strings.Builder
+	pending    string
+	done       bool
+}
+
+// Write passes a streamed chunk through the filter
+func (f *narrationFilter) Write(delta string) {
+	if f.done {
+		return
+	}
+	f.pending += delta
+
+	if idx := strings.Index(f.pending, stateMarker); idx >= 0 {
+		f.emit(f.pending[:idx])
+		f.pending = ""
+		f.done = true
+		if f.onComplete != nil {
+			f.onComplete(f.narration.String())
+		}
+		return
+	}
+
+	hold := 0
+	for k := len(stateMarker) - 1; k > 0; k-- {
+		if strings.HasSuffix(f.pending, stateMarker[:k]) {
+			hold = k
+			break
+		}
+	}
+	f.emit(f.pending[:len(f.pending)-hold])
+	f.pending = f.pending[len(f.pending)-hold:]
+}
+
+// Flush forwards any held-back text once the stream has ended
+func (f *narrationFilter) Flush() {
+	if !f.done {
+		f.emit(f.pending)
+	}
+	f.pending = ""
+}
+
+func (f *narrationFilter) emit(text string) {
+	if text != "" {
+		f.narration.WriteString(text)
+		f.onNarration(text)
+	}
+}
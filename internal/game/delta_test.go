@@ -0,0 +1,200 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSplitTurnReply(t *testing.T) {
+	narration, raw := splitTurnReply("You find a key.\n" + stateMarker + "\n```json\n{\"items_gained\": []}\n```")
+	if narration != "You find a key." {
+		t.Errorf("Unexpected narration %q", narration)
+	}
+	if string(raw) != `{"items_gained": []}` {
+		t.Errorf("Unexpected delta %s", raw)
+	}
+
+	narration, raw = splitTurnReply("Nothing happens.")
+	if narration != "Nothing happens." || raw != nil {
+		t.Errorf("Reply without marker should be all narration, got %q / %s", narration, raw)
+	}
+}
+
+func TestApplyStateDelta(t *testing.T) {
+	state := NewGameState()
+	state.World.moveTo(state.World.addLocation("Gate", "An iron gate."))
+	state.Player.Inventory = []Item{{Name: "Torch", Quantity: 2}}
+	state.Player.Stats["health"] = 10
+
+	delta, err := parseStateDelta([]byte(`{
+		"items_gained": [{"name": "torch"}, {"name": "rusty key", "description": "Old", "quantity": 1}, {"name": " "}],
+		"items_lost": [{"name": "torch", "quantity": 5}, {"name": "sword"}],
+		"stat_changes": {"health": -3, "luck": 1, "wits": 0},
+		"location": "Courtyard",
+		"location_description": "A weedy courtyard.",
+		"status": "bruised"
+	}`))
+	if err != nil {
+		t.Fatalf("parseStateDelta returned error: %v", err)
+	}
+
+	changes := applyStateDelta(state, delta)
+
+	want := []string{
+		"Gained: torch (x1)",
+		"Gained: rusty key (x1)",
+		"Lost: torch (x3)",
+		"health -3 (now 7)",
+		"luck +1 (now 1)",
+		"Location: Courtyard",
+		"Status: bruised",
+	}
+	if strings.Join(changes, "|") != strings.Join(want, "|") {
+		t.Errorf("Unexpected changes:\n got %q\nwant %q", changes, want)
+	}
+
+	if len(state.Player.Inventory) != 1 || state.Player.Inventory[0].Name != "rusty key" {
+		t.Errorf("Unexpected inventory %+v", state.Player.Inventory)
+	}
+	if courtyard := state.World.Current(); courtyard == nil || courtyard.Name != "Courtyard" ||
+		courtyard.Description != "A weedy courtyard." || !courtyard.Visited {
+		t.Errorf("Location not applied: %+v", state.World)
+	}
+	if back := state.World.Current().exitTo("Gate"); back == nil {
+		t.Errorf("Expected a way back to the gate, got %+v", state.World.Current().Exits)
+	}
+	if state.Player.Status != "bruised" {
+		t.Errorf("Status not applied: %q", state.Player.Status)
+	}
+}
+
+func TestApplyStateDeltaWithoutStats(t *testing.T) {
+	state := NewGameState()
+	state.Player.Stats = nil
+
+	delta, err := parseStateDelta([]byte(`{"stat_changes": {"health": -2}}`))
+	if err != nil {
+		t.Fatalf("parseStateDelta returned error: %v", err)
+	}
+	changes := applyStateDelta(state, delta)
+
+	if len(changes) != 1 || changes[0] != "health -2 (now -2)" || state.Player.Stats["health"] != -2 {
+		t.Errorf("Expected the stat to be created, got %q and %v", changes, state.Player.Stats)
+	}
+}
+
+func TestApplyStateDeltaExits(t *testing.T) {
+	state := NewGameState()
+	gate := state.World.addLocation("Gate", "An iron gate.")
+	state.World.connect(gate, Exit{Direction: "north", To: "Courtyard", Locked: true})
+	state.World.connect(gate, Exit{Direction: "down", To: "Cellar", Hidden: true})
+	state.World.moveTo(gate)
+
+	delta, err := parseStateDelta([]byte(`{"unlock_exits": ["n", "east"], "reveal_exits": ["down", "north"]}`))
+	if err != nil {
+		t.Fatalf("parseStateDelta returned error: %v", err)
+	}
+	changes := applyStateDelta(state, delta)
+
+	want := []string{"Unlocked: the way north to Courtyard", "Discovered: a way down to Cellar"}
+	if strings.Join(changes, "|") != strings.Join(want, "|") {
+		t.Errorf("Unexpected changes:\n got %q\nwant %q", changes, want)
+	}
+	if gate.exit("north").Locked || gate.exit("down").Hidden {
+		t.Errorf("Exits not updated: %+v", gate.Exits)
+	}
+}
+
+func TestApplyStateDeltaLinksMoves(t *testing.T) {
+	state := NewGameState()
+	gate := state.World.addLocation("Gate", "An iron gate.")
+	state.World.connect(gate, Exit{Direction: "north", To: "Courtyard"})
+	state.World.moveTo(gate)
+
+	delta, err := parseStateDelta([]byte(`{"location": "Well", "location_description": "A dry well.", "direction": "n"}`))
+	if err != nil {
+		t.Fatalf("parseStateDelta returned error: %v", err)
+	}
+	applyStateDelta(state, delta)
+
+	// North is taken, so the move is linked the first free way instead
+	well := state.World.Location("Well")
+	if exit := gate.exitTo("Well"); exit == nil || exit.Direction != "east" {
+		t.Errorf("Expected the gate to lead east to the well, got %+v", gate.Exits)
+	}
+	if back := well.exit("west"); back == nil || back.To != "Gate" {
+		t.Errorf("Expected a way back west to the gate, got %+v", well.Exits)
+	}
+
+	// Moving back along an existing exit adds nothing
+	delta, _ = parseStateDelta([]byte(`{"location": "Gate", "direction": "south"}`))
+	applyStateDelta(state, delta)
+	if len(gate.Exits) != 2 || len(well.Exits) != 1 {
+		t.Errorf("Expected no new exits, got %+v and %+v", gate.Exits, well.Exits)
+	}
+}
+
+func TestParseStateDeltaMalformed(t *testing.T) {
+	if _, err := parseStateDelta([]byte(`{"stat_changes": {"health": "lots"}}`)); err == nil {
+		t.Error("Expected error for malformed delta")
+	}
+}
+
+func TestNarrationFilter(t *testing.T) {
+	var streamed strings.Builder
+	filter := &narrationFilter{onNarration: func(delta string) { streamed.WriteString(delta) }}
+
+	for _, chunk := range []string{"The door ", "creaks open.\n--", "-STA", "TE---\n{\"status\": ", "\"wary\"}"} {
+		filter.Write(chunk)
+	}
+	filter.Flush()
+
+	if streamed.String() != "The door creaks open.\n" {
+		t.Errorf("Filter leaked state delta: %q", streamed.String())
+	}
+
+	streamed.Reset()
+	filter = &narrationFilter{onNarration: func(delta string) { streamed.WriteString(delta) }}
+	filter.Write("A dash -")
+	filter.Write("- of hope")
+	filter.Write(" --")
+	filter.Flush()
+
+	if streamed.String() != "A dash -- of hope --" {
+		t.Errorf("Filter dropped narration: %q", streamed.String())
+	}
+}
+
+func TestProcessPlayerActionAppliesStateDelta(t *testing.T) {
+	engine, provider := newReplyEngine(t,
+		"You pry a silver coin from the mud.\n"+stateMarker+"\n"+`{"items_gained": [{"name": "silver coin", "quantity": 1}]}`)
+	state := NewGameState()
+
+	var streamed strings.Builder
+	err := engine.ProcessPlayerActionStream(context.Background(), state, "search the mud", func(delta string) {
+		streamed.WriteString(delta)
+	})
+	if err != nil {
+		t.Fatalf("ProcessPlayerActionStream returned error: %v", err)
+	}
+
+	if strings.Contains(streamed.String(), stateMarker) {
+		t.Errorf("State delta should not be streamed: %q", streamed.String())
+	}
+	if !strings.Contains(strings.Join(provider.requests[0].Context, "\n"), stateMarker) {
+		t.Error("Game master should be asked for a state delta")
+	}
+	if len(state.Player.Inventory) != 1 || state.Player.Inventory[0].Name != "silver coin" {
+		t.Errorf("Inventory not updated: %+v", state.Player.Inventory)
+	}
+
+	narrator := state.History[1]
+	if narrator.Type != entryTypeNarrator || narrator.Content != "You pry a silver coin from the mud." {
+		t.Errorf("Unexpected narration entry %+v", narrator)
+	}
+	last := state.History[len(state.History)-1]
+	if last.Type != entryTypeSystem || last.Content != "Gained: silver coin (x1)" {
+		t.Errorf("Expected applied change as system entry, got %+v", last)
+	}
+}
@@ -1,14 +1,28 @@
 package game
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"axon/internal/ai"
 	"axon/internal/config"
 	"axon/internal/logger"
 )
 
+// defaultMaxTokens are the reply limits for tasks whose configuration sets none
+var defaultMaxTokens = map[string]int{
+	"world_building": 800,
+	"storytelling":   600,
+	"dialog":         600,
+	"rule_setting":   200,
+	"summarization":  500,
+}
+
+// Prompt context budget for action suggestions, which only need recent narration
+const suggestionContextTokens = 600
+
 const (
 	// String constants for entry types
 	entryTypePlayer   = "player"
@@ -20,82 +34,70 @@ const (
 type Engine struct {
 	aiClient *ai.Client
 	config   *config.Config
+	dice     *dice
 }
 
 // NewEngine creates a new game engine
 func NewEngine(cfg *config.Config) *Engine {
-	aiClient := ai.NewClient(cfg.AI.OpenRouterAPIKey, cfg.AI.GeminiAPIKey)
+	aiClient := ai.NewClientFromConfig(cfg.AI)
 	return &Engine{
 		aiClient: aiClient,
 		config:   cfg,
+		dice:     newDice(cfg.Game.DiceSeed),
 	}
 }
 
 // InitializeWorld creates the initial game world based on a seed prompt
-func (e *Engine) InitializeWorld(state *GameState, seedPrompt string) error {
+func (e *Engine) InitializeWorld(ctx context.Context, state *GameState, seedPrompt string) error {
 	logger.Info("Starting world initialization with prompt: %s", seedPrompt)
 	logger.LogWorldCreation("start", seedPrompt)
 
 	// Use the best model for world building
-	model := e.aiClient.GetBestModel("world_building")
-	logger.Debug("Selected model for world building: %s", model)
+	req := e.taskRequest("world_building")
+	logger.Debug("Selected model for world building: %s", req.Model)
 
-	// Create simplified context for world generation
-	context := []string{
+	promptContext := []string{
 		"You are creating a world for a text-based adventure game.",
-		"Create a brief, engaging world description based on the user's prompt.",
-		"Keep your response concise and immersive.",
+		"Build an engaging, coherent world based on the user's prompt.",
+		worldSchemaPrompt,
 	}
 
-	prompt := fmt.Sprintf("Create a world: %s. Describe the setting in 2-3 sentences.", seedPrompt)
+	prompt := fmt.Sprintf("Create a world: %s", seedPrompt)
 	logger.Debug("World creation prompt: %s", prompt)
-	logger.LogWorldCreation("context", context)
+	logger.LogWorldCreation("context", promptContext)
 
-	req := ai.Request{
-		Prompt:    prompt,
-		Model:     model,
-		MaxTokens: 200, // Reduced for faster response
-		Context:   context,
-	}
+	req.Prompt = prompt
+	req.Context = promptContext
 
 	logger.LogWorldCreation("request", req)
 
 	logger.Info("Sending world creation request to AI")
-	resp, err := e.aiClient.Generate(req)
-	if err != nil {
-		logger.Error("AI world generation failed: %v", err)
-		return fmt.Errorf("failed to generate world: %w", err)
-	}
+	taskCtx, cancel := e.taskContext(ctx, "world_building")
+	defer cancel()
+
+	var spec *worldSpec
+	err := e.generateJSON(taskCtx, state, req, func(raw []byte) error {
+		parsed, err := parseWorldSpec(raw)
+		spec = parsed
+		return err
+	})
 
-	logger.LogWorldCreation("response", resp)
+	if ctx.Err() != nil {
+		logger.Info("World initialization cancelled: %v", ctx.Err())
+		return ctx.Err()
+	}
 
-	if resp.Error != nil {
-		logger.Error("AI response contains error: %v", resp.Error)
+	if err != nil {
+		logger.Error("AI world generation failed: %v", err)
 		logger.LogWorldCreation("fallback", "using themed world based on prompt")
 		// Create themed fallback world based on the seed prompt
-		themeWorld := e.createThemedWorld(seedPrompt)
-		state.World.Name = themeWorld.Name
-		state.World.Description = themeWorld.Description
-		state.World.Setting = themeWorld.Setting
-		state.World.Rules = themeWorld.Rules
-		state.World.CurrentLocation = themeWorld.CurrentLocation
-		state.World.Locations[themeWorld.CurrentLocation] = themeWorld.Description
-		state.AddHistoryEntry(entryTypeNarrator, themeWorld.Description)
-		state.AddHistoryEntry(
-			entryTypeNarrator,
-			"The mists of reality shimmer and coalesce, drawing upon ancient memories and forgotten tales to weave this world into existence...",
-		)
+		applyThemedWorld(state, e.createThemedWorld(seedPrompt))
+		state.AddHistoryEntry(entryTypeSystem, "This world was built from a template because the AI was unavailable. "+
+			describeAIError(err))
 	} else {
-		logger.Info("AI world creation successful, parsing response")
-		logger.LogWorldCreation("ai_success", resp.Text)
-		// Parse AI response and populate world
-		state.World.Name = "Generated World"
-		state.World.Description = resp.Text
-		state.World.Setting = "AI Generated"
-		state.World.Rules = []string{"AI-driven narrative", "Player choices matter"}
-		state.World.CurrentLocation = "Starting Point"
-		state.World.Locations["Starting Point"] = resp.Text
-		state.AddHistoryEntry(entryTypeNarrator, resp.Text)
+		logger.Info("AI world creation successful")
+		logger.LogWorldCreation("ai_success", spec)
+		applyWorldSpec(state, spec)
 	}
 
 	logger.LogGameState(state)
@@ -104,156 +106,314 @@ func (e *Engine) InitializeWorld(state *GameState, seedPrompt string) error {
 }
 
 // ProcessPlayerAction processes a player action and generates response
-func (e *Engine) ProcessPlayerAction(state *GameState, action string) error {
+func (e *Engine) ProcessPlayerAction(ctx context.Context, state *GameState, action string) error {
+	return e.ProcessPlayerActionStream(ctx, state, action, nil)
+}
+
+// ProcessPlayerActionStream processes a player action, passing narration to
+// onNarration as it streams in, then runs memory upkeep. A nil onNarration
+// waits for the full reply.
+func (e *Engine) ProcessPlayerActionStream(
+	ctx context.Context, state *GameState, action string, onNarration ai.StreamHandler,
+) error {
+	if err := e.ProcessPlayerActionWithHandlers(ctx, state, action, ActionHandlers{Narration: onNarration}); err != nil {
+		return err
+	}
+	e.MaintainMemory(ctx, state)
+	return nil
+}
+
+// MaintainMemory runs the upkeep that follows a turn: folding older history
+// into the story summary and archiving history past the configured limit.
+// Memory failures are logged and retried next turn; they never cost the player the turn.
+func (e *Engine) MaintainMemory(ctx context.Context, state *GameState) {
+	_ = e.UpdateMemory(ctx, state)
+
+	if archived := state.CompactHistory(e.config.Game.HistoryLimit); archived > 0 {
+		logger.Info("Archived %d history entries (%d archived in total)", archived, len(state.Archive))
+	}
+}
+
+// ActionHandlers receive progress while a player action is processed
+type ActionHandlers struct {
+	// Narration receives narration as it streams in; nil waits for the full reply
+	Narration ai.StreamHandler
+	// Width is the number of columns command output, such as the map, is
+	// shown in; zero uses the configured terminal width
+	Width int
+	// Narrated receives a copy of the state holding the turn's narration as
+	// soon as the narration is complete, while the state delta may still be
+	// arriving, so follow-up work such as suggestions can start early. It is
+	// not called for engine commands, blocked moves or failed requests.
+	Narrated func(snapshot *GameState)
+}
+
+// ProcessPlayerActionWithHandlers processes a player action, reporting
+// progress to handlers. It returns as soon as the turn's narration and state
+// changes are applied; memory upkeep is left to MaintainMemory, so callers
+// can hand the turn to the player first.
+func (e *Engine) ProcessPlayerActionWithHandlers(
+	ctx context.Context, state *GameState, action string, handlers ActionHandlers,
+) error {
+	onNarration := handlers.Narration
 	logger.Info("Processing player action: %s", action)
 	// Add player action to history
 	state.AddHistoryEntry(entryTypePlayer, action)
 
-	// Get recent history for context
-	recentHistory := state.GetRecentHistory(10)
-	contextLines := make([]string, 0)
-
-	// Build context from recent history
-	for _, entry := range recentHistory {
-		contextLines = append(contextLines, fmt.Sprintf("%s: %s", entry.Type, entry.Content))
+	cmd := ParseCommand(action)
+	if isEngineCommand(cmd) {
+		return e.handleSystemAction(state, action, handlers.Width)
 	}
 
-	// Choose appropriate model based on action type
-	var model string
-
-	actionLower := strings.ToLower(action)
-	switch {
-	case strings.Contains(actionLower, "say") || strings.Contains(actionLower, "talk") || strings.Contains(actionLower, "speak"):
-		model = e.aiClient.GetBestModel("dialog")
-	case strings.Contains(actionLower, "inventory") || strings.Contains(actionLower, "stats"):
-		return e.handleSystemAction(state, action)
+	// Moves the location graph can settle are made before the game master narrates them
+	instructions := []string{"Respond to the player's action with narrative description. Keep responses concise but engaging."}
+	switch e.navigate(ctx, state, cmd) {
+	case moveBlocked:
+		return nil
+	case moveArrived:
+		instructions = append(instructions, fmt.Sprintf(
+			"The player has just arrived at %s. Narrate the journey and arrival; the move is already recorded, so leave location out of the state delta.",
+			state.World.CurrentLocation))
 	default:
-		model = e.aiClient.GetBestModel("storytelling")
+		// Uncertain actions are settled by the dice before they are narrated
+		if check := e.resolveSkillCheck(ctx, state, cmd); check != nil {
+			instructions = append(instructions, check.promptLine())
+		}
 	}
-
-	// Create AI request
-	context := []string{
-		"You are the Game Master for a text-based adventure game.",
-		fmt.Sprintf("World: %s - %s", state.World.Name, state.World.Description),
-		fmt.Sprintf("Current Location: %s", state.World.CurrentLocation),
-		fmt.Sprintf("Player: %s - %s", state.Player.Name, state.Player.Description),
-		"Recent game history:",
-		strings.Join(contextLines, "\n"),
-		"Respond to the player's action with narrative description. Keep responses concise but engaging.",
+	if ctx.Err() != nil {
+		logger.Info("Action processing cancelled: %v", ctx.Err())
+		return ctx.Err()
 	}
 
+	// Choose appropriate model based on action type
+	task := "storytelling"
+	var npc *NPC
+	if cmd.IsDialog() {
+		task = "dialog"
+		npc = state.npcAddressed(cmd)
+	}
+	req := e.taskRequest(task)
+
+	// Fit the world setup and as much recent history as the model allows
+	setup := []string{"You are the Game Master for a text-based adventure game."}
+	setup = append(setup, worldSetupLines(state)...)
+	if npc != nil {
+		// Conversations with a known character are voiced from their persona and memories
+		setup = append(setup, npc.personaLines()...)
+		instructions = append(instructions, fmt.Sprintf(
+			"Voice %s's reply in character, shaped by their attitude and what they remember.", npc.Name))
+	}
+	setup = append(setup, "Recent game history:")
+
+	budget := e.aiClient.ContextBudget(req.Model)
+	built := buildContext(contextParts{
+		Setup: setup,
+		// Older entries are covered by the story summary; the action itself is sent as the prompt
+		History:      recentTurns(state),
+		Instructions: append(instructions, turnFormatPrompts(npc)...),
+	}, budget)
+	logContext(task, budget, built)
+	promptContext := built.Lines
+
 	prompt := fmt.Sprintf("Player action: %s", action)
 
-	req := ai.Request{
-		Prompt:    prompt,
-		Model:     model,
-		MaxTokens: 500,
-		Context:   context,
-	}
+	req.Prompt = prompt
+	req.Context = promptContext
 
 	logger.Info("Sending action request to AI")
-	resp, err := e.aiClient.Generate(req)
-	if err != nil {
-		logger.Error("AI action processing failed: %v", err)
-		return fmt.Errorf("failed to generate response: %w", err)
+	taskCtx, cancel := e.taskContext(ctx, task)
+	defer cancel()
+	// The narration is handed on once, as soon as it is complete
+	narrated := false
+	reportNarrated := func(narration string) {
+		if narrated || handlers.Narrated == nil {
+			return
+		}
+		narrated = true
+		reportNarration(state, narration, handlers.Narrated)
 	}
 
-	if resp.Error != nil {
-		logger.Error("AI response contains error: %v", resp.Error)
-		// Immersive fallback response based on action type
-		fallbackResponse := e.generateFallbackResponse(action, state)
-		state.AddHistoryEntry(entryTypeNarrator, fallbackResponse)
+	var resp *ai.Response
+	var err error
+	if onNarration != nil {
+		// Only the narration is streamed; the state delta after the marker is not for the player
+		filter := &narrationFilter{onNarration: onNarration, onComplete: reportNarrated}
+		resp, err = e.aiClient.GenerateStream(taskCtx, req, filter.Write)
+		filter.Flush()
 	} else {
+		resp, err = e.aiClient.Generate(taskCtx, req)
+	}
+	e.recordUsage(state, task, resp)
+
+	if ctx.Err() != nil {
+		logger.Info("Action processing cancelled: %v", ctx.Err())
+		return ctx.Err()
+	}
+
+	narration, rawDelta := splitTurnReply(resp.Text)
+
+	switch {
+	case err != nil && strings.TrimSpace(narration) != "":
+		// Keep whatever narration made it through before the stream broke off
+		logger.Error("AI narration interrupted: %v", err)
+		state.AddHistoryEntry(entryTypeNarrator, narration)
+		state.AddHistoryEntry(entryTypeSystem, "The narration was interrupted. "+describeAIError(err))
+	case err != nil:
+		logger.Error("AI action processing failed: %v", err)
+		// Immersive fallback response based on action type, then what went wrong
+		fallbackResponse := e.generateFallbackResponse(cmd, state)
+		state.AddHistoryEntry(entryTypeNarrator, fallbackResponse)
+		state.AddHistoryEntry(entryTypeSystem, describeAIError(err))
+	default:
 		logger.Info("AI action processing successful")
 		logger.Debug("AI response: %s", resp.Text)
-		state.AddHistoryEntry(entryTypeNarrator, resp.Text)
+		reportNarrated(narration)
+		state.AddHistoryEntry(entryTypeNarrator, narration)
+		e.applyTurnDelta(state, rawDelta)
+		if npc != nil {
+			applyDialogOutcome(state, npc, action, rawDelta)
+		}
 	}
 
 	// Advance turn
 	state.NextTurn()
-
 	return nil
 }
 
-// handleSystemAction handles system actions like inventory, stats, etc.
-func (e *Engine) handleSystemAction(state *GameState, action string) error {
-	actionLower := strings.ToLower(action)
+// reportNarration hands onNarrated a copy of the state with the turn's
+// narration added, leaving the state itself to the rest of the turn
+func reportNarration(state *GameState, narration string, onNarrated func(snapshot *GameState)) {
+	narration = strings.TrimSpace(narration)
+	if narration == "" {
+		return
+	}
+	snapshot, err := state.Clone()
+	if err != nil {
+		logger.Error("Not reporting narration early: %v", err)
+		return
+	}
+	snapshot.AddHistoryEntry(entryTypeNarrator, narration)
+	onNarrated(snapshot)
+}
 
-	switch {
-	case strings.Contains(actionLower, "inventory"):
-		if len(state.Player.Inventory) == 0 {
-			state.AddHistoryEntry(entryTypeSystem, "Your inventory is empty.")
-		} else {
-			inventoryList := "Inventory:\n"
-			for _, item := range state.Player.Inventory {
-				inventoryList += fmt.Sprintf("- %s (x%d): %s\n", item.Name, item.Quantity, item.Description)
-			}
-			state.AddHistoryEntry(entryTypeSystem, inventoryList)
-		}
+// turnFormatPrompts describe the reply format for a turn, which also reports
+// how a conversation went when the player is speaking to a character
+func turnFormatPrompts(npc *NPC) []string {
+	if npc == nil {
+		return []string{stateDeltaPrompt}
+	}
+	return []string{stateDeltaPrompt, dialogOutcomePrompt}
+}
 
-	case strings.Contains(actionLower, "stats"):
-		if len(state.Player.Stats) == 0 {
-			state.AddHistoryEntry(entryTypeSystem, "No stats to display.")
-		} else {
-			statsList := "Character Stats:\n"
-			for stat, value := range state.Player.Stats {
-				statsList += fmt.Sprintf("- %s: %d\n", stat, value)
-			}
-			state.AddHistoryEntry(entryTypeSystem, statsList)
+// recentTurns returns the history not yet folded into the story summary,
+// leaving out the action being processed and anything recorded since it
+func recentTurns(state *GameState) []HistoryEntry {
+	recent := state.unsummarizedHistory()
+	for i := len(recent) - 1; i >= 0; i-- {
+		if recent[i].Type == entryTypePlayer {
+			return recent[:i]
 		}
+	}
+	return recent
+}
 
-	case strings.Contains(actionLower, "help"):
-		helpText := `Available commands:
-- Type any action to interact with the world
-- 'inventory' or 'inv' to check your items
-- 'stats' to view character statistics
-- 'save [name]' to save your game
-- 'load [name]' to load a saved game
-- 'quit' to exit the game`
-		state.AddHistoryEntry(entryTypeSystem, helpText)
+// applyTurnDelta validates and applies the state delta from a game-master
+// reply, recording each applied change as a system entry
+func (e *Engine) applyTurnDelta(state *GameState, rawDelta []byte) {
+	if rawDelta == nil {
+		logger.Debug("AI response carried no state delta")
+		return
+	}
+	delta, err := parseStateDelta(rawDelta)
+	if err != nil {
+		logger.Error("Ignoring state delta: %v", err)
+		return
+	}
+	for _, change := range applyStateDelta(state, delta) {
+		state.AddHistoryEntry(entryTypeSystem, change)
+	}
+}
 
-	default:
-		state.AddHistoryEntry(entryTypeSystem, "Unknown system command. Type 'help' for available commands.")
+// taskRequest starts an AI request for a task using its configured model and
+// generation settings, with the built-in reply limit when none is configured
+func (e *Engine) taskRequest(task string) ai.Request {
+	req := e.aiClient.TaskRequest(task)
+	if req.MaxTokens <= 0 {
+		req.MaxTokens = defaultMaxTokens[task]
 	}
+	return req
+}
 
+// taskContext derives a context carrying the configured deadline for an AI task
+func (e *Engine) taskContext(ctx context.Context, task string) (context.Context, context.CancelFunc) {
+	if seconds := e.config.AI.TaskTimeouts[task]; seconds > 0 {
+		return context.WithTimeout(ctx, time.Duration(seconds)*time.Second)
+	}
+	return context.WithCancel(ctx)
+}
+
+// QueueStatus reports the AI requests currently held back by rate limits
+func (e *Engine) QueueStatus() ai.QueueStatus {
+	return e.aiClient.QueueStatus()
+}
+
+// handleSystemAction handles system actions like inventory, stats, etc.,
+// laying out their output for the given width
+func (e *Engine) handleSystemAction(state *GameState, action string, width int) error {
+	cmd := ParseCommand(action)
+	builtin, ok := lookupBuiltin(cmd.Verb)
+	if !ok || builtin.run == nil {
+		state.AddHistoryEntry(entryTypeSystem, "Unknown system command. Type 'help' for available commands.")
+		return nil
+	}
+	if width <= 0 {
+		width = e.config.Terminal.Width
+	}
+	builtin.run(e, state, cmd, width)
 	return nil
 }
 
 // GenerateActionSuggestions generates suggested actions for the player
-func (e *Engine) GenerateActionSuggestions(state *GameState) ([]string, error) {
-	model := e.aiClient.GetBestModel("rule_setting")
-
-	context := []string{
-		"Generate 3-4 brief action suggestions for the player in this situation.",
-		fmt.Sprintf("World: %s", state.World.Name),
-		fmt.Sprintf("Location: %s", state.World.CurrentLocation),
-		"Provide only the action suggestions, one per line, without numbers or bullets.",
-	}
-
-	// Get recent context
-	recentHistory := state.GetRecentHistory(3)
-	contextLines := make([]string, 0)
-	for _, entry := range recentHistory {
-		if entry.Type == entryTypeNarrator {
-			contextLines = append(contextLines, entry.Content)
-		}
-	}
+func (e *Engine) GenerateActionSuggestions(ctx context.Context, state *GameState) ([]string, error) {
+	req := e.taskRequest("rule_setting")
+
+	// Suggestions only need the latest narration, so they get a smaller budget
+	budget := min(e.aiClient.ContextBudget(req.Model), suggestionContextTokens)
+	built := buildContext(contextParts{
+		Setup: []string{
+			"Generate 3-4 brief action suggestions for the player in this situation.",
+			fmt.Sprintf("World: %s", state.World.Name),
+			fmt.Sprintf("Location: %s", state.World.CurrentLocation),
+			"Recent narration:",
+		},
+		History: state.History,
+		Instructions: []string{
+			"Provide only the action suggestions, one per line, without numbers or bullets.",
+		},
+		Format: func(entry HistoryEntry) string {
+			if entry.Type != entryTypeNarrator {
+				return ""
+			}
+			return entry.Content
+		},
+	}, budget)
+	logContext("rule_setting", budget, built)
+	promptContext := built.Lines
 
-	prompt := "Current situation: " + strings.Join(contextLines, " ")
+	prompt := "Suggest what the player could do next."
 
-	req := ai.Request{
-		Prompt:    prompt,
-		Model:     model,
-		MaxTokens: 200,
-		Context:   context,
-	}
+	req.Prompt = prompt
+	req.Context = promptContext
 
-	resp, err := e.aiClient.Generate(req)
-	if err != nil {
-		return []string{"Look around", "Continue forward", "Check inventory"}, nil
+	taskCtx, cancel := e.taskContext(ctx, "rule_setting")
+	defer cancel()
+	resp, err := e.aiClient.Generate(taskCtx, req)
+	e.recordUsage(state, "rule_setting", resp)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
-
-	if resp.Error != nil {
+	if err != nil {
+		logger.Debug("Suggestion generation failed: %v", err)
 		return []string{"Look around", "Continue forward", "Check inventory"}, nil
 	}
 
@@ -331,7 +491,7 @@ func (e *Engine) createCyberpunkWorld() *World {
 			"Trust no one",
 		},
 		CurrentLocation: "Underground District",
-		Locations:       make(map[string]string),
+		Locations:       make(map[string]*Location),
 	}
 }
 
@@ -347,7 +507,7 @@ func (e *Engine) createFantasyWorld() *World {
 			"Knowledge is power",
 		},
 		CurrentLocation: "Village Edge",
-		Locations:       make(map[string]string),
+		Locations:       make(map[string]*Location),
 	}
 }
 
@@ -363,7 +523,7 @@ func (e *Engine) createSpaceWorld() *World {
 			"Survival is paramount",
 		},
 		CurrentLocation: "Station Corridor",
-		Locations:       make(map[string]string),
+		Locations:       make(map[string]*Location),
 	}
 }
 
@@ -374,7 +534,7 @@ func (e *Engine) createApocalypticWorld() *World {
 		Setting:         "Post-Apocalyptic",
 		Rules:           []string{"Resources are scarce", "Trust is earned", "The past is gone", "Adapt or perish"},
 		CurrentLocation: "Wasteland Outpost",
-		Locations:       make(map[string]string),
+		Locations:       make(map[string]*Location),
 	}
 }
 
@@ -390,16 +550,14 @@ func (e *Engine) createDefaultWorld() *World {
 			"Reality is flexible",
 		},
 		CurrentLocation: "Starting Point",
-		Locations:       make(map[string]string),
+		Locations:       make(map[string]*Location),
 	}
 }
 
 // generateFallbackResponse creates immersive fallback responses when AI is unavailable
-func (e *Engine) generateFallbackResponse(action string, state *GameState) string {
-	actionLower := strings.ToLower(action)
-
+func (e *Engine) generateFallbackResponse(cmd Command, state *GameState) string {
 	// Try to match action with predefined responses
-	if response := e.tryMatchActionType(actionLower); response != "" {
+	if response := e.tryMatchActionType(cmd.Verb); response != "" {
 		return response
 	}
 
@@ -407,79 +565,81 @@ func (e *Engine) generateFallbackResponse(action string, state *GameState) strin
 	return e.getDefaultFallbackResponse(state)
 }
 
-func (e *Engine) tryMatchActionType(actionLower string) string {
-	if e.isMovementAction(actionLower) {
+func (e *Engine) tryMatchActionType(verb string) string {
+	if e.isMovementAction(verb) {
 		return "You move through the area, your footsteps echoing softly as you explore your surroundings. The path ahead remains shrouded in mystery, waiting for your next decision."
 	}
-	if e.isObservationAction(actionLower) {
+	if e.isObservationAction(verb) {
 		return "You take a moment to carefully observe your surroundings. Details emerge from the shadows - subtle signs and hidden clues that might prove important on your journey."
 	}
-	if e.isSearchAction(actionLower) {
+	if e.isSearchAction(verb) {
 		return "You search methodically, running your hands along surfaces and peering into dark corners. Though nothing immediately reveals itself, you sense that persistence might yet yield results."
 	}
-	if e.isCombatAction(actionLower) {
+	if e.isCombatAction(verb) {
 		return "Your muscles tense as you prepare for conflict. The air crackles with tension, and you feel the familiar rush of adrenaline coursing through your veins."
 	}
-	if e.isTakingAction(actionLower) {
+	if e.isTakingAction(verb) {
 		return "You reach out carefully, your fingers closing around the object. A sense of acquisition fills you as you secure this new addition to your belongings."
 	}
-	if e.isCommunicationAction(actionLower) {
+	if e.isCommunicationAction(verb) {
 		return "Your words hang in the air, carrying with them the weight of intention. Whether anyone is listening remains to be seen, but you have made your voice heard."
 	}
-	if e.isOpeningAction(actionLower) {
+	if e.isOpeningAction(verb) {
 		return "With determined effort, you work to overcome the obstacle before you. Progress is slow but steady, and you sense that your persistence will eventually pay off."
 	}
-	if e.isRestingAction(actionLower) {
+	if e.isRestingAction(verb) {
 		return "Time passes quietly as you pause in your journey. The world continues its ancient rhythms around you, and you feel a moment of peace amidst the uncertainty."
 	}
-	if e.isEscapeAction(actionLower) {
+	if e.isEscapeAction(verb) {
 		return "Your heart pounds as you move swiftly away from potential danger. The landscape blurs past you as survival instincts take over, guiding your hurried steps."
 	}
 	return ""
 }
 
-func (e *Engine) isMovementAction(action string) bool {
-	return strings.Contains(action, "go") || strings.Contains(action, "walk") || strings.Contains(action, "move") ||
-		strings.Contains(action, "head")
+func (e *Engine) isMovementAction(verb string) bool {
+	return isOneOf(verb, "go", "enter", "climb", "cross", "follow", "return")
+}
+
+func (e *Engine) isObservationAction(verb string) bool {
+	return isOneOf(verb, "look", "examine", "observe", "watch", "study", "read")
 }
 
-func (e *Engine) isObservationAction(action string) bool {
-	return strings.Contains(action, "look") || strings.Contains(action, "examine") ||
-		strings.Contains(action, "observe") ||
-		strings.Contains(action, "inspect")
+func (e *Engine) isSearchAction(verb string) bool {
+	return isOneOf(verb, "search", "find", "seek", "explore")
 }
 
-func (e *Engine) isSearchAction(action string) bool {
-	return strings.Contains(action, "search") || strings.Contains(action, "find") || strings.Contains(action, "seek")
+func (e *Engine) isCombatAction(verb string) bool {
+	return isOneOf(verb, "attack", "fight", "strike", "hit", "kill", "stab", "shoot")
 }
 
-func (e *Engine) isCombatAction(action string) bool {
-	return strings.Contains(action, "attack") || strings.Contains(action, "fight") ||
-		strings.Contains(action, "strike") ||
-		strings.Contains(action, "hit")
+func (e *Engine) isTakingAction(verb string) bool {
+	return isOneOf(verb, "take", "pick", "collect", "steal")
 }
 
-func (e *Engine) isTakingAction(action string) bool {
-	return strings.Contains(action, "take") || strings.Contains(action, "grab") || strings.Contains(action, "pick") ||
-		strings.Contains(action, "get")
+func (e *Engine) isCommunicationAction(verb string) bool {
+	return dialogVerbs[verb]
 }
 
-func (e *Engine) isCommunicationAction(action string) bool {
-	return strings.Contains(action, "say") || strings.Contains(action, "speak") || strings.Contains(action, "talk") ||
-		strings.Contains(action, "tell")
+func (e *Engine) isOpeningAction(verb string) bool {
+	return isOneOf(verb, "open", "unlock", "break", "force", "pry")
 }
 
-func (e *Engine) isOpeningAction(action string) bool {
-	return strings.Contains(action, "open") || strings.Contains(action, "unlock") || strings.Contains(action, "break")
+func (e *Engine) isRestingAction(verb string) bool {
+	return isOneOf(verb, "wait", "rest", "pause", "sit", "sleep", "stay")
 }
 
-func (e *Engine) isRestingAction(action string) bool {
-	return strings.Contains(action, "wait") || strings.Contains(action, "rest") || strings.Contains(action, "pause") ||
-		strings.Contains(action, "sit")
+func (e *Engine) isEscapeAction(verb string) bool {
+	return isOneOf(verb, "run", "flee", "escape", "hide")
 }
 
-func (e *Engine) isEscapeAction(action string) bool {
-	return strings.Contains(action, "run") || strings.Contains(action, "flee") || strings.Contains(action, "escape")
+// isOneOf reports whether verb is one of the given verbs
+func isOneOf(verb string, verbs ...string) bool {
+	for _, v := range verbs {
+		if verb == v {
+			return true
+		}
+	}
+	return false
 }
 
 func (e *Engine) getDefaultFallbackResponse(state *GameState) string {
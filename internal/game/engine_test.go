@@ -1,12 +1,66 @@
 package game
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 	"testing"
 
+	"axon/internal/ai"
 	"axon/internal/config"
 )
 
+// brokenStreamProvider streams part of a reply before failing
+type brokenStreamProvider struct{}
+
+func (brokenStreamProvider) Generate(ctx context.Context, req ai.Request) (*ai.Response, error) {
+	return nil, fmt.Errorf("stream only")
+}
+
+func (brokenStreamProvider) GenerateStream(ctx context.Context, req ai.Request, onDelta ai.StreamHandler) (*ai.Response, error) {
+	onDelta("You step into the ")
+	return &ai.Response{Text: "You step into the "}, fmt.Errorf("connection reset")
+}
+
+// replyProvider returns canned replies in order, repeating the last one,
+// and records every request it receives
+type replyProvider struct {
+	replies  []string
+	requests []ai.Request
+	// usage is reported with every reply
+	usage ai.Usage
+}
+
+func (p *replyProvider) Generate(ctx context.Context, req ai.Request) (*ai.Response, error) {
+	p.requests = append(p.requests, req)
+	reply := p.replies[len(p.replies)-1]
+	if len(p.requests) <= len(p.replies) {
+		reply = p.replies[len(p.requests)-1]
+	}
+	return &ai.Response{Text: reply, Usage: p.usage}, nil
+}
+
+// newReplyEngine creates an engine whose AI calls are answered by a replyProvider
+func newReplyEngine(t *testing.T, replies ...string) (*Engine, *replyProvider) {
+	t.Helper()
+	provider := &replyProvider{replies: replies}
+	providerType := "test_reply_" + t.Name()
+	ai.RegisterProvider(providerType, func(cfg config.ProviderConfig, httpClient *http.Client) (ai.Provider, error) {
+		return provider, nil
+	})
+	cfg := &config.Config{
+		AI: config.AIConfig{
+			DefaultProvider: "replies",
+			Providers: map[string]config.ProviderConfig{
+				"replies": {Type: providerType},
+			},
+		},
+	}
+	return NewEngine(cfg), provider
+}
+
 func TestNewEngine(t *testing.T) {
 	cfg := &config.Config{
 		AI: config.AIConfig{
@@ -43,7 +97,7 @@ func TestInitializeWorld(t *testing.T) {
 	engine := NewEngine(cfg)
 	state := NewGameState()
 
-	err := engine.InitializeWorld(state, "A fantasy world with dragons")
+	err := engine.InitializeWorld(context.Background(), state, "A fantasy world with dragons")
 	if err != nil {
 		t.Errorf("InitializeWorld should not return error, got %v", err)
 	}
@@ -84,13 +138,13 @@ func TestProcessPlayerAction(t *testing.T) {
 	state := NewGameState()
 
 	// Initialize world first
-	if err := engine.InitializeWorld(state, "test world"); err != nil {
+	if err := engine.InitializeWorld(context.Background(), state, "test world"); err != nil {
 		t.Fatal(err)
 	}
 	initialHistoryCount := len(state.History)
 	initialTurn := state.Turn
 
-	err := engine.ProcessPlayerAction(state, "look around")
+	err := engine.ProcessPlayerAction(context.Background(), state, "look around")
 	if err != nil {
 		t.Errorf("ProcessPlayerAction should not return error, got %v", err)
 	}
@@ -129,7 +183,7 @@ func TestHandleSystemAction(t *testing.T) {
 	state := NewGameState()
 
 	// Test inventory command with empty inventory
-	err := engine.handleSystemAction(state, "inventory")
+	err := engine.handleSystemAction(state, "inventory", 0)
 	if err != nil {
 		t.Errorf("handleSystemAction should not return error, got %v", err)
 	}
@@ -149,7 +203,7 @@ func TestHandleSystemAction(t *testing.T) {
 
 	// Test stats command with empty stats
 	state.History = []HistoryEntry{} // Clear history
-	err = engine.handleSystemAction(state, "stats")
+	err = engine.handleSystemAction(state, "stats", 0)
 	if err != nil {
 		t.Errorf("handleSystemAction should not return error, got %v", err)
 	}
@@ -160,7 +214,7 @@ func TestHandleSystemAction(t *testing.T) {
 
 	// Test help command
 	state.History = []HistoryEntry{} // Clear history
-	err = engine.handleSystemAction(state, "help")
+	err = engine.handleSystemAction(state, "help", 0)
 	if err != nil {
 		t.Errorf("handleSystemAction should not return error, got %v", err)
 	}
@@ -191,7 +245,7 @@ func TestHandleSystemActionWithInventory(t *testing.T) {
 		{Name: "potion", Description: "healing liquid", Quantity: 3},
 	}
 
-	err := engine.handleSystemAction(state, "inventory")
+	err := engine.handleSystemAction(state, "inventory", 0)
 	if err != nil {
 		t.Errorf("handleSystemAction should not return error, got %v", err)
 	}
@@ -226,7 +280,7 @@ func TestHandleSystemActionWithStats(t *testing.T) {
 		"mana":   50,
 	}
 
-	err := engine.handleSystemAction(state, "stats")
+	err := engine.handleSystemAction(state, "stats", 0)
 	if err != nil {
 		t.Errorf("handleSystemAction should not return error, got %v", err)
 	}
@@ -263,7 +317,7 @@ func TestGenerateActionSuggestions(t *testing.T) {
 	state.AddHistoryEntry("narrator", "You find yourself in a dark forest.")
 	state.AddHistoryEntry("narrator", "There is a path ahead.")
 
-	suggestions, err := engine.GenerateActionSuggestions(state)
+	suggestions, err := engine.GenerateActionSuggestions(context.Background(), state)
 	if err != nil {
 		t.Errorf("GenerateActionSuggestions should not return error, got %v", err)
 	}
@@ -281,3 +335,185 @@ func TestGenerateActionSuggestions(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessPlayerActionStreamKeepsPartialText(t *testing.T) {
+	ai.RegisterProvider("test_broken_stream", func(cfg config.ProviderConfig, httpClient *http.Client) (ai.Provider, error) {
+		return brokenStreamProvider{}, nil
+	})
+	cfg := &config.Config{
+		AI: config.AIConfig{
+			DefaultProvider: "broken",
+			Providers: map[string]config.ProviderConfig{
+				"broken": {Type: "test_broken_stream"},
+			},
+		},
+	}
+
+	engine := NewEngine(cfg)
+	state := NewGameState()
+
+	var streamed strings.Builder
+	err := engine.ProcessPlayerActionStream(context.Background(), state, "enter the cave", func(delta string) {
+		streamed.WriteString(delta)
+	})
+	if err != nil {
+		t.Fatalf("ProcessPlayerActionStream should not return error, got %v", err)
+	}
+
+	if streamed.String() != "You step into the " {
+		t.Errorf("Expected streamed delta, got %q", streamed.String())
+	}
+
+	var narration string
+	for _, entry := range state.History {
+		if entry.Type == entryTypeNarrator {
+			narration = entry.Content
+		}
+	}
+	if narration != "You step into the " {
+		t.Errorf("Partial narration should be kept, got %q", narration)
+	}
+
+	last := state.History[len(state.History)-1]
+	if last.Type != entryTypeSystem || !strings.Contains(last.Content, "interrupted") {
+		t.Errorf("Expected interruption notice, got %+v", last)
+	}
+}
+
+func TestProcessPlayerActionCancelled(t *testing.T) {
+	cfg := &config.Config{}
+	engine := NewEngine(cfg)
+	state := NewGameState()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := engine.ProcessPlayerAction(ctx, state, "look around")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	for _, entry := range state.History {
+		if entry.Type == entryTypeNarrator {
+			t.Error("Cancelled action should not produce fallback narration")
+		}
+	}
+}
+
+func TestTaskRequestUsesConfiguredSettings(t *testing.T) {
+	engine, provider := newReplyEngine(t, "The lantern flickers.")
+	engine.aiClient = ai.NewClientFromConfig(config.AIConfig{
+		DefaultModel:    "default/model",
+		DefaultProvider: "replies",
+		Providers:       map[string]config.ProviderConfig{"replies": {Type: "test_reply_" + t.Name()}},
+		Tasks: map[string]config.TaskConfig{
+			"storytelling": {Model: "story/model", MaxTokens: 321},
+		},
+	})
+
+	if err := engine.ProcessPlayerAction(context.Background(), NewGameState(), "light the lantern"); err != nil {
+		t.Fatalf("ProcessPlayerAction returned error: %v", err)
+	}
+	if req := provider.requests[0]; req.Model != "story/model" || req.MaxTokens != 321 {
+		t.Errorf("Expected configured model and limit, got %s / %d", req.Model, req.MaxTokens)
+	}
+
+	if req := engine.taskRequest("rule_setting"); req.Model != "default/model" || req.MaxTokens != 200 {
+		t.Errorf("Expected default model and built-in limit, got %s / %d", req.Model, req.MaxTokens)
+	}
+}
+
+func TestProcessPlayerActionLeavesUpkeepToCaller(t *testing.T) {
+	engine, provider := newReplyEngine(t, "The gate creaks open.", summaryJSON)
+	state := NewGameState()
+	fillHistory(state, summarizeThreshold)
+
+	if err := engine.ProcessPlayerActionWithHandlers(context.Background(), state, "open the gate", ActionHandlers{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.requests) != 1 || state.Turn != 1 || state.Memory.Summary != "" {
+		t.Fatalf("Expected the turn to finish before memory upkeep, got %d requests", len(provider.requests))
+	}
+
+	engine.MaintainMemory(context.Background(), state)
+	if len(provider.requests) != 2 || provider.requests[1].Task != "summarization" || state.Memory.Summary == "" {
+		t.Errorf("Expected upkeep to summarize older history, got %d requests", len(provider.requests))
+	}
+}
+
+func TestProcessPlayerActionReportsNarrationEarly(t *testing.T) {
+	for _, streamed := range []bool{false, true} {
+		engine, _ := newReplyEngine(t, "The gate creaks open.\n"+stateMarker+"\n{\"status\": \"wary\"}")
+		state := NewGameState()
+
+		var snapshots []*GameState
+		handlers := ActionHandlers{Narrated: func(snapshot *GameState) { snapshots = append(snapshots, snapshot) }}
+		if streamed {
+			handlers.Narration = func(string) {}
+		}
+		if err := engine.ProcessPlayerActionWithHandlers(context.Background(), state, "open the gate", handlers); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(snapshots) != 1 {
+			t.Fatalf("Expected the narration to be reported once (streamed %v), got %d", streamed, len(snapshots))
+		}
+		snapshot := snapshots[0]
+		if last := snapshot.History[len(snapshot.History)-1]; last.Type != entryTypeNarrator || last.Content != "The gate creaks open." {
+			t.Errorf("Expected the snapshot to end with the narration, got %+v", last)
+		}
+		// The snapshot is taken before the state delta is applied
+		if snapshot.Player.Status != "" || state.Player.Status != "wary" {
+			t.Errorf("Expected the delta on the state only, got %q and %q", snapshot.Player.Status, state.Player.Status)
+		}
+	}
+}
+
+// failingProvider fails every request with the same error
+type failingProvider struct {
+	err error
+}
+
+func (p failingProvider) Generate(ctx context.Context, req ai.Request) (*ai.Response, error) {
+	return nil, p.err
+}
+
+func TestAIFailuresAreExplained(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("%w: OpenRouter API key not configured", ai.ErrAuth), "API key"},
+		{&ai.APIError{StatusCode: 402, Message: "Insufficient credits"}, "out of credits"},
+		{&ai.APIError{StatusCode: 429, Message: "Rate limit exceeded"}, "rate limiting"},
+		{fmt.Errorf("%w: gemini blocked the response", ai.ErrContentFiltered), "safety filter"},
+		{fmt.Errorf("%w: slow", ai.ErrTimeout), "too long"},
+		{fmt.Errorf("%w: empty", ai.ErrMalformed), "could not use"},
+		{fmt.Errorf("connection refused"), "network"},
+	}
+
+	for i, test := range tests {
+		providerType := fmt.Sprintf("test_failing_%d", i)
+		ai.RegisterProvider(providerType, func(cfg config.ProviderConfig, httpClient *http.Client) (ai.Provider, error) {
+			return failingProvider{err: test.err}, nil
+		})
+		engine := NewEngine(&config.Config{AI: config.AIConfig{
+			DefaultProvider: "failing",
+			Providers:       map[string]config.ProviderConfig{"failing": {Type: providerType}},
+		}})
+
+		state := NewGameState()
+		if err := engine.ProcessPlayerAction(context.Background(), state, "look around"); err != nil {
+			t.Fatalf("Failures should fall back rather than error, got %v", err)
+		}
+
+		// The fallback narration is followed by an explanation of the failure
+		last := state.History[len(state.History)-1]
+		if last.Type != entryTypeSystem || !strings.Contains(last.Content, test.want) {
+			t.Errorf("For %v expected a system entry mentioning %q, got %+v", test.err, test.want, last)
+		}
+		if narration := state.History[len(state.History)-2]; narration.Type != entryTypeNarrator {
+			t.Errorf("Expected fallback narration before the explanation, got %+v", narration)
+		}
+	}
+}
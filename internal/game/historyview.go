@@ -0,0 +1,40 @@
+package game
+
+// historyView caches the wrapped lines of the history panel so each render
+// only wraps entries added since the last one. Long campaigns would
+// otherwise re-wrap thousands of entries on every keypress.
+type historyView struct {
+	width int
+	// first and last identify the entries the cached lines were built from
+	first, last HistoryEntry
+	count       int
+	lines       []string
+}
+
+// sameEntry reports whether two history entries are the same entry. Clones
+// lose the monotonic clock reading, so timestamps are compared with Equal.
+func sameEntry(a, b HistoryEntry) bool {
+	return a.Type == b.Type && a.Turn == b.Turn && a.Content == b.Content && a.Timestamp.Equal(b.Timestamp)
+}
+
+// linesFor returns the wrapped lines for history at the given width, reusing
+// cached lines when history has only grown since the last call
+func (v *historyView) linesFor(history []HistoryEntry, width int, format func(HistoryEntry) []string) []string {
+	reusable := v.width == width && v.count > 0 && len(history) >= v.count &&
+		sameEntry(history[0], v.first) && sameEntry(history[v.count-1], v.last)
+	if !reusable {
+		v.width = width
+		v.count = 0
+		v.lines = v.lines[:0]
+	}
+
+	for _, entry := range history[v.count:] {
+		v.lines = append(v.lines, format(entry)...)
+	}
+	v.count = len(history)
+	if v.count > 0 {
+		v.first = history[0]
+		v.last = history[v.count-1]
+	}
+	return v.lines
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"axon/internal/config"
+)
+
+func TestHistoryViewWrapsOnlyNewEntries(t *testing.T) {
+	view := &historyView{}
+	formatted := 0
+	format := func(entry HistoryEntry) []string {
+		formatted++
+		return []string{entry.Content}
+	}
+
+	state := NewGameState()
+	for i := 0; i < 100; i++ {
+		state.AddHistoryEntry(entryTypeNarrator, fmt.Sprintf("entry %d", i))
+	}
+
+	view.linesFor(state.History, 80, format)
+	if formatted != 100 {
+		t.Fatalf("First render should format every entry, formatted %d", formatted)
+	}
+
+	state.AddHistoryEntry(entryTypePlayer, "look")
+	clone, err := state.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := view.linesFor(clone.History, 80, format)
+	if formatted != 101 || len(lines) != 101 || lines[100] != "look" {
+		t.Errorf("Only the new entry should be formatted, formatted %d, %d lines", formatted, len(lines))
+	}
+
+	view.linesFor(state.History, 60, format)
+	if formatted != 202 {
+		t.Errorf("A width change should re-wrap everything, formatted %d", formatted)
+	}
+
+	state.CompactHistory(50)
+	lines = view.linesFor(state.History, 60, format)
+	if len(lines) != len(state.History) || lines[0] != state.History[0].Content {
+		t.Errorf("Compacted history should be rebuilt, got %d lines for %d entries", len(lines), len(state.History))
+	}
+}
+
+func TestSameEntryIgnoresMonotonicClock(t *testing.T) {
+	now := time.Now()
+	entry := HistoryEntry{Type: entryTypeNarrator, Content: "x", Timestamp: now}
+	stripped := entry
+	stripped.Timestamp = now.Round(0)
+
+	if !sameEntry(entry, stripped) {
+		t.Error("Entries differing only in monotonic clock should match")
+	}
+}
+
+func TestRenderHistoryWithLargeHistory(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 80, Height: 24}}
+	m := NewModel(cfg, createTestTerminalInfo())
+	m.mode = ModePlaying
+	m.scrollOffset = -1
+	for i := 0; i < 5000; i++ {
+		m.gameState.AddHistoryEntry(entryTypeNarrator, fmt.Sprintf("The tide turns for the %dth time.", i))
+	}
+
+	first := m.renderHistory(12)
+	m.gameState.AddHistoryEntry(entryTypePlayer, "wait")
+	second := m.renderHistory(12)
+
+	if !strings.Contains(first, "4999th") {
+		t.Errorf("Latest entry should be visible:\n%s", first)
+	}
+	if !strings.HasSuffix(second, "> wait") {
+		t.Errorf("New entry should be rendered at the bottom:\n%s", second)
+	}
+
+	m.pendingAction = "listen"
+	m.streamText = "Gulls cry."
+	third := m.renderHistory(12)
+	if !strings.HasSuffix(third, "> listen\nGulls cry.") {
+		t.Errorf("In-flight action should follow history:\n%s", third)
+	}
+	if m.history.lines[len(m.history.lines)-1] != "> wait" {
+		t.Error("In-flight lines must not leak into the cache")
+	}
+}
+
+func TestProcessPlayerActionCompactsHistory(t *testing.T) {
+	engine, _ := newReplyEngine(t, "Time passes.")
+	engine.config.Game.HistoryLimit = 40
+	state := NewGameState()
+	for i := 0; i < 40; i++ {
+		state.AddHistoryEntry(entryTypeNarrator, fmt.Sprintf("entry %d", i))
+	}
+	// Keep summarization out of the way
+	state.Memory.SummarizedThrough = 35
+
+	if err := engine.ProcessPlayerAction(context.Background(), state, "wait"); err != nil {
+		t.Fatalf("ProcessPlayerAction returned error: %v", err)
+	}
+	if len(state.History) > 40 || len(state.Archive) == 0 {
+		t.Errorf("History should be compacted to the limit, got %d entries and %d archived",
+			len(state.History), len(state.Archive))
+	}
+	if len(state.History)+len(state.Archive) != 42 {
+		t.Errorf("No entries should be lost, got %d", len(state.History)+len(state.Archive))
+	}
+}
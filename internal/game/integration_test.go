@@ -1,6 +1,7 @@
 package game
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -47,7 +48,7 @@ func TestWorldGenerationIntegration(t *testing.T) {
 			testState := NewGameState()
 
 			// Test world initialization
-			err := engine.InitializeWorld(testState, tc.prompt)
+			err := engine.InitializeWorld(context.Background(), testState, tc.prompt)
 			if err != nil {
 				t.Errorf("InitializeWorld failed: %v", err)
 				return
@@ -114,7 +115,7 @@ func TestPlayerActionsIntegration(t *testing.T) {
 	state := NewGameState()
 
 	// Initialize world first
-	err := engine.InitializeWorld(state, "A simple tavern in a fantasy town")
+	err := engine.InitializeWorld(context.Background(), state, "A simple tavern in a fantasy town")
 	if err != nil {
 		t.Fatalf("Failed to initialize world: %v", err)
 	}
@@ -140,7 +141,7 @@ func TestPlayerActionsIntegration(t *testing.T) {
 				time.Sleep(1 * time.Second)
 			}
 
-			err := engine.ProcessPlayerAction(state, action)
+			err := engine.ProcessPlayerAction(context.Background(), state, action)
 			if err != nil {
 				t.Errorf("ProcessPlayerAction failed for '%s': %v", action, err)
 				return
@@ -213,13 +214,13 @@ func TestActionSuggestionsIntegration(t *testing.T) {
 	state := NewGameState()
 
 	// Initialize world
-	err := engine.InitializeWorld(state, "A mysterious forest clearing with an ancient stone circle")
+	err := engine.InitializeWorld(context.Background(), state, "A mysterious forest clearing with an ancient stone circle")
 	if err != nil {
 		t.Fatalf("Failed to initialize world: %v", err)
 	}
 
 	// Test action suggestions
-	suggestions, err := engine.GenerateActionSuggestions(state)
+	suggestions, err := engine.GenerateActionSuggestions(context.Background(), state)
 	if err != nil {
 		t.Errorf("GenerateActionSuggestions failed: %v", err)
 		return
@@ -241,7 +242,7 @@ func TestActionSuggestionsIntegration(t *testing.T) {
 	}
 
 	// Test that suggestions change after player actions
-	err = engine.ProcessPlayerAction(state, "examine the stone circle")
+	err = engine.ProcessPlayerAction(context.Background(), state, "examine the stone circle")
 	if err != nil {
 		t.Errorf("Failed to process player action: %v", err)
 		return
@@ -250,7 +251,7 @@ func TestActionSuggestionsIntegration(t *testing.T) {
 	// Add delay to avoid rate limiting
 	time.Sleep(1 * time.Second)
 
-	newSuggestions, err := engine.GenerateActionSuggestions(state)
+	newSuggestions, err := engine.GenerateActionSuggestions(context.Background(), state)
 	if err != nil {
 		t.Errorf("GenerateActionSuggestions failed after action: %v", err)
 		return
@@ -266,3 +267,73 @@ func TestActionSuggestionsIntegration(t *testing.T) {
 		t.Logf("  %d. %s", i+1, suggestion)
 	}
 }
+
+// TestScriptedSessionIntegration plays a full session against the scripted
+// provider, so it runs deterministically without network access
+func TestScriptedSessionIntegration(t *testing.T) {
+	cfg := &config.Config{
+		AI: config.AIConfig{
+			DefaultModel:    "scripted/model",
+			DefaultProvider: "script",
+			Providers: map[string]config.ProviderConfig{
+				"script": {Type: "scripted", Fixture: "testdata/scripted_session.json"},
+			},
+		},
+		Game: config.GameConfig{HistoryLimit: 1000},
+	}
+	engine := NewEngine(cfg)
+	state := NewGameState()
+	ctx := context.Background()
+
+	if err := engine.InitializeWorld(ctx, state, "a drowned village"); err != nil {
+		t.Fatalf("InitializeWorld failed: %v", err)
+	}
+	if state.World.Name != "Hollowmere" || state.World.CurrentLocation != "Jetty" {
+		t.Fatalf("Expected scripted world, got %s at %s", state.World.Name, state.World.CurrentLocation)
+	}
+
+	var streamed strings.Builder
+	if err := engine.ProcessPlayerActionStream(ctx, state, "pick up the key", func(delta string) {
+		streamed.WriteString(delta)
+	}); err != nil {
+		t.Fatalf("ProcessPlayerActionStream failed: %v", err)
+	}
+	if streamed.String() != "You pull a rusted iron key from between the planks.\n" {
+		t.Errorf("Unexpected streamed narration %q", streamed.String())
+	}
+
+	for _, action := range []string{"go to the chapel", "say is anyone there?", "listen", "wait"} {
+		if err := engine.ProcessPlayerAction(ctx, state, action); err != nil {
+			t.Fatalf("ProcessPlayerAction(%q) failed: %v", action, err)
+		}
+	}
+
+	if len(state.Player.Inventory) != 2 || state.Player.Inventory[1].Name != "iron key" {
+		t.Errorf("Expected lantern and iron key, got %+v", state.Player.Inventory)
+	}
+	if state.World.CurrentLocation != "Chapel" || state.Player.Stats["nerve"] != 5 {
+		t.Errorf("Expected move to Chapel with nerve 5, got %s / %d", state.World.CurrentLocation, state.Player.Stats["nerve"])
+	}
+	if state.Turn != 5 {
+		t.Errorf("Expected 5 turns, got %d", state.Turn)
+	}
+
+	var narration []string
+	for _, entry := range state.History {
+		if entry.Type == entryTypeNarrator {
+			narration = append(narration, entry.Content)
+		}
+	}
+	last := narration[len(narration)-2:]
+	if last[0] != "The lake is still. Somewhere a bell tolls once." || last[1] != "Mist curls around your ankles." {
+		t.Errorf("Expected scripted replies in order, got %q", last)
+	}
+
+	suggestions, err := engine.GenerateActionSuggestions(ctx, state)
+	if err != nil {
+		t.Fatalf("GenerateActionSuggestions failed: %v", err)
+	}
+	if strings.Join(suggestions, "|") != "Ring the bell|Search the pews|Return to the jetty" {
+		t.Errorf("Unexpected suggestions %q", suggestions)
+	}
+}
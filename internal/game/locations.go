@@ -0,0 +1,376 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"axon/internal/logger"
+)
+
+// locationSchemaPrompt describes the JSON object the world-building model
+// must return for a location the player reaches for the first time
+const locationSchemaPrompt = `Respond with a single JSON object and nothing else, using this shape:
+{
+  "name": "location name",
+  "description": "one or two sentences",
+  "exits": [{"direction": "east", "to": "name of the location it leads to", "locked": false, "hidden": false}]
+}
+Exit directions are north, south, east, west, northeast, northwest, southeast, southwest, up or down.
+The way back is added automatically. Exits may lead to known locations or new ones.`
+
+// Location is a node in the world's location graph
+type Location struct {
+	Name string `json:"name"`
+	// Description is empty for a location known only by name, such as the far
+	// end of an exit; it is generated when the player first arrives
+	Description string `json:"description"`
+	Exits       []Exit `json:"exits,omitempty"`
+	Visited     bool   `json:"visited,omitempty"`
+}
+
+// Exit is a one-way connection from one location to another
+type Exit struct {
+	// Direction is one of the canonical directions, such as "north" or "up"
+	Direction string `json:"direction"`
+	To        string `json:"to"`
+	// Locked exits are known but cannot be taken until the game master unlocks them
+	Locked bool `json:"locked,omitempty"`
+	// Hidden exits are known only to the game master until revealed
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// oppositeDirections maps each canonical direction to the way back
+var oppositeDirections = map[string]string{
+	"north": "south", "south": "north", "east": "west", "west": "east",
+	"northeast": "southwest", "southwest": "northeast",
+	"northwest": "southeast", "southeast": "northwest",
+	"up": "down", "down": "up",
+}
+
+// canonicalDirection resolves a direction or its abbreviation, returning ""
+// for anything that is not a direction
+func canonicalDirection(direction string) string {
+	return directionAliases[strings.ToLower(strings.TrimSpace(direction))]
+}
+
+// UnmarshalJSON decodes a location, accepting the bare description string
+// that saves from before the location graph stored
+func (l *Location) UnmarshalJSON(data []byte) error {
+	var description string
+	if err := json.Unmarshal(data, &description); err == nil {
+		*l = Location{Description: description}
+		return nil
+	}
+	type plain Location
+	return json.Unmarshal(data, (*plain)(l))
+}
+
+// UnmarshalJSON decodes a world, naming locations loaded from older saves
+func (w *World) UnmarshalJSON(data []byte) error {
+	type plain World
+	if err := json.Unmarshal(data, (*plain)(w)); err != nil {
+		return err
+	}
+	if w.Locations == nil {
+		w.Locations = make(map[string]*Location)
+	}
+	for name, loc := range w.Locations {
+		if loc == nil {
+			w.Locations[name] = &Location{Name: name}
+		} else if loc.Name == "" {
+			loc.Name = name
+		}
+	}
+	return nil
+}
+
+// Location returns the named location, matching names case-insensitively
+func (w *World) Location(name string) *Location {
+	if loc, ok := w.Locations[name]; ok {
+		return loc
+	}
+	for key, loc := range w.Locations {
+		if strings.EqualFold(key, name) {
+			return loc
+		}
+	}
+	return nil
+}
+
+// Current returns the player's current location, or nil if it is not in the graph
+func (w *World) Current() *Location {
+	return w.Location(w.CurrentLocation)
+}
+
+// addLocation returns the named location, creating it if it is unknown. A
+// description fills in one that is still empty.
+func (w *World) addLocation(name, description string) *Location {
+	if w.Locations == nil {
+		w.Locations = make(map[string]*Location)
+	}
+	loc := w.Location(name)
+	if loc == nil {
+		loc = &Location{Name: name}
+		w.Locations[name] = loc
+	}
+	if loc.Description == "" {
+		loc.Description = description
+	}
+	return loc
+}
+
+// connect adds an exit from one location to another unless the location
+// already has an exit that way, creating the destination if it is unknown.
+// The way back is added too when the destination has no exit in that direction.
+func (w *World) connect(from *Location, exit Exit) {
+	if from.exit(exit.Direction) != nil || strings.EqualFold(from.Name, exit.To) {
+		return
+	}
+	to := w.addLocation(exit.To, "")
+	exit.To = to.Name
+	from.Exits = append(from.Exits, exit)
+
+	back := oppositeDirections[exit.Direction]
+	if back != "" && to.exit(back) == nil && to.exitTo(from.Name) == nil {
+		to.Exits = append(to.Exits, Exit{Direction: back, To: from.Name, Locked: exit.Locked})
+	}
+}
+
+// link connects two locations the player has travelled between, unless an
+// exit already joins them. The direction is the one the player went, or if
+// that is unknown or taken, the first one free at both ends, so the map can
+// still draw the way back.
+func (w *World) link(from, to *Location, direction string) {
+	if from == to || from.exitTo(to.Name) != nil || to.exitTo(from.Name) != nil {
+		return
+	}
+	free := func(direction string) bool {
+		back := oppositeDirections[direction]
+		return back != "" && from.exit(direction) == nil && to.exit(back) == nil
+	}
+	if !free(direction) {
+		direction = ""
+		for _, candidate := range directionOrder {
+			if free(candidate) {
+				direction = candidate
+				break
+			}
+		}
+	}
+	if direction != "" {
+		w.connect(from, Exit{Direction: direction, To: to.Name})
+	}
+}
+
+// moveTo makes a location the current one and marks it visited
+func (w *World) moveTo(loc *Location) {
+	w.CurrentLocation = loc.Name
+	loc.Visited = true
+}
+
+// exit returns the exit in a direction, hidden or not
+func (l *Location) exit(direction string) *Exit {
+	for i := range l.Exits {
+		if l.Exits[i].Direction == direction {
+			return &l.Exits[i]
+		}
+	}
+	return nil
+}
+
+// exitTo returns the exit leading to the named location, hidden or not
+func (l *Location) exitTo(name string) *Exit {
+	for i := range l.Exits {
+		if strings.EqualFold(l.Exits[i].To, name) {
+			return &l.Exits[i]
+		}
+	}
+	return nil
+}
+
+// findExit returns the visible exit a movement target names, either by
+// direction or by destination
+func (l *Location) findExit(target string) *Exit {
+	exit := l.exitTo(target)
+	if direction := canonicalDirection(target); direction != "" {
+		exit = l.exit(direction)
+	}
+	if exit == nil || exit.Hidden {
+		return nil
+	}
+	return exit
+}
+
+// describeExits lists a location's exits on one line for the game master,
+// including the hidden ones the player has not found
+func (l *Location) describeExits() string {
+	if len(l.Exits) == 0 {
+		return "none known"
+	}
+	exits := make([]string, 0, len(l.Exits))
+	for _, exit := range l.Exits {
+		text := fmt.Sprintf("%s to %s", exit.Direction, exit.To)
+		switch {
+		case exit.Hidden && exit.Locked:
+			text += " (hidden, locked)"
+		case exit.Hidden:
+			text += " (hidden)"
+		case exit.Locked:
+			text += " (locked)"
+		}
+		exits = append(exits, text)
+	}
+	return strings.Join(exits, ", ")
+}
+
+// locationNames lists every known location name in alphabetical order
+func (w *World) locationNames() []string {
+	names := make([]string, 0, len(w.Locations))
+	for _, loc := range w.Locations {
+		names = append(names, loc.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseLocationSpec decodes and validates a generated location JSON object
+func parseLocationSpec(raw []byte) (*locationSpec, error) {
+	var spec locationSpec
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("malformed location JSON: %w", err)
+	}
+	spec.Name = strings.TrimSpace(spec.Name)
+	spec.Description = strings.TrimSpace(spec.Description)
+	if spec.Description == "" {
+		return nil, errors.New("location is missing a description")
+	}
+	spec.Exits = normalizeExits(spec.Exits)
+	return &spec, nil
+}
+
+// movement is how the engine resolved a movement command
+type movement int
+
+const (
+	// moveUnresolved leaves the command to the game master
+	moveUnresolved movement = iota
+	// moveBlocked means there is no way through; the player has been told why
+	moveBlocked
+	// moveArrived means the player is now at the new location
+	moveArrived
+)
+
+// navigate resolves a movement command against the location graph. Commands
+// whose target is neither a direction nor the destination of an exit are
+// left to the game master. Going in a direction without an exit is blocked,
+// unless the location has no known exits at all: then the world is still
+// open that way and a new location is generated there.
+func (e *Engine) navigate(ctx context.Context, state *GameState, cmd Command) movement {
+	from := state.World.Current()
+	if from == nil || cmd.Object == "" || !e.isMovementAction(cmd.Verb) {
+		return moveUnresolved
+	}
+
+	direction := canonicalDirection(cmd.Object)
+	var to *Location
+	switch exit := from.findExit(cmd.Object); {
+	case exit != nil && exit.Locked:
+		state.AddHistoryEntry(entryTypeSystem, fmt.Sprintf("The way %s to %s is locked.", exit.Direction, exit.To))
+		return moveBlocked
+	case exit != nil:
+		to = state.World.addLocation(exit.To, "")
+		direction = exit.Direction
+	case direction == "" || cmd.Verb != "go":
+		return moveUnresolved
+	case len(from.Exits) > 0:
+		state.AddHistoryEntry(entryTypeSystem, fmt.Sprintf("You can't go %s from here.", direction))
+		return moveBlocked
+	}
+
+	if to == nil || to.Description == "" {
+		to = e.exploreLocation(ctx, state, from, direction, to)
+	}
+	state.World.moveTo(to)
+	state.AddHistoryEntry(entryTypeSystem, fmt.Sprintf("Location: %s", to.Name))
+	return moveArrived
+}
+
+// exploreLocation generates a location the player is entering for the first
+// time, going in direction from another. to is the location's placeholder
+// when an exit already names it, or nil to let the model name it. If
+// generation fails the location is left undescribed so the next visit tries
+// again.
+func (e *Engine) exploreLocation(ctx context.Context, state *GameState, from *Location, direction string, to *Location) *Location {
+	promptContext := []string{
+		"You are expanding the map of a text-based adventure game.",
+		fmt.Sprintf("World: %s - %s", state.World.Name, state.World.Description),
+	}
+	if len(state.World.Rules) > 0 {
+		promptContext = append(promptContext, "World rules: "+strings.Join(state.World.Rules, "; "))
+	}
+	promptContext = append(promptContext,
+		"Known locations: "+strings.Join(state.World.locationNames(), ", "),
+		fmt.Sprintf("The player is going %s from %s - %s", direction, from.Name, from.Description),
+		locationSchemaPrompt,
+	)
+
+	req := e.taskRequest("world_building")
+	req.Prompt = "Describe the location the player arrives at."
+	if to != nil {
+		req.Prompt = fmt.Sprintf("Describe %s, the location the player arrives at.", to.Name)
+	}
+	req.Context = promptContext
+
+	taskCtx, cancel := e.taskContext(ctx, "world_building")
+	defer cancel()
+
+	var spec *locationSpec
+	err := e.generateJSON(taskCtx, state, req, func(raw []byte) error {
+		parsed, err := parseLocationSpec(raw)
+		spec = parsed
+		return err
+	})
+	if err != nil {
+		logger.Error("Location generation failed: %v", err)
+		if to == nil {
+			to = state.World.addLocation(fallbackLocationName(from.Name, direction), "")
+			state.World.connect(from, Exit{Direction: direction, To: to.Name})
+		}
+		return to
+	}
+
+	if to == nil {
+		name := spec.Name
+		if name == "" {
+			name = fallbackLocationName(from.Name, direction)
+		}
+		to = state.World.addLocation(name, "")
+		state.World.connect(from, Exit{Direction: direction, To: to.Name})
+	}
+	if to.Description == "" {
+		// The model may name a place the player has already seen; keep what they saw
+		to.Description = spec.Description
+	}
+	for _, exit := range spec.Exits {
+		if to.exitTo(exit.To) == nil {
+			state.World.connect(to, exit)
+		}
+	}
+	logger.Info("Generated location %s with %d exits", to.Name, len(to.Exits))
+	return to
+}
+
+// fallbackLocationName names a new location after where it lies from another
+func fallbackLocationName(from, direction string) string {
+	switch direction {
+	case "up":
+		return "Above " + from
+	case "down":
+		return "Below " + from
+	}
+	return strings.ToUpper(direction[:1]) + direction[1:] + " of " + from
+}
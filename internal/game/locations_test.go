@@ -0,0 +1,130 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestWorldLoadsOldLocations(t *testing.T) {
+	var world World
+	data := `{"name": "Old", "locations": {"Gate": "An iron gate."}, "current_location": "Gate"}`
+	if err := json.Unmarshal([]byte(data), &world); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	gate := world.Current()
+	if gate == nil || gate.Name != "Gate" || gate.Description != "An iron gate." {
+		t.Errorf("Expected the old location to load as a node, got %+v", gate)
+	}
+}
+
+func TestConnectAddsWayBack(t *testing.T) {
+	world := &World{}
+	gate := world.addLocation("Gate", "An iron gate.")
+	world.connect(gate, Exit{Direction: "north", To: "courtyard"})
+	world.connect(gate, Exit{Direction: "north", To: "Stables"})
+
+	if len(gate.Exits) != 1 {
+		t.Fatalf("Expected one exit north, got %+v", gate.Exits)
+	}
+	courtyard := world.Location("Courtyard")
+	if courtyard == nil || courtyard.Description != "" {
+		t.Fatalf("Expected an undescribed placeholder for the destination, got %+v", courtyard)
+	}
+	if back := courtyard.exit("south"); back == nil || back.To != "Gate" {
+		t.Errorf("Expected a way back south, got %+v", courtyard.Exits)
+	}
+}
+
+func TestNavigateAlongExits(t *testing.T) {
+	engine, provider := newReplyEngine(t,
+		`{"name": "ignored", "description": "A weedy courtyard.", "exits": [{"direction": "east", "to": "Stables"}]}`,
+		"You step into the courtyard.\n---STATE---\n{}",
+	)
+	state := NewGameState()
+	gate := state.World.addLocation("Gate", "An iron gate.")
+	state.World.connect(gate, Exit{Direction: "north", To: "Courtyard"})
+	state.World.connect(gate, Exit{Direction: "east", To: "Vault", Locked: true})
+	state.World.connect(gate, Exit{Direction: "down", To: "Cellar", Hidden: true})
+	state.World.moveTo(gate)
+	ctx := context.Background()
+
+	for action, want := range map[string]string{
+		"go east": "The way east to Vault is locked.",
+		"go down": "You can't go down from here.",
+		"w":       "You can't go west from here.",
+	} {
+		if err := engine.ProcessPlayerAction(ctx, state, action); err != nil {
+			t.Fatal(err)
+		}
+		if last := state.History[len(state.History)-1]; last.Content != want {
+			t.Errorf("%s: expected %q, got %q", action, want, last.Content)
+		}
+	}
+	if len(provider.requests) != 0 || state.Turn != 0 {
+		t.Fatalf("Blocked moves should not reach the AI or take a turn, got %d requests", len(provider.requests))
+	}
+
+	if err := engine.ProcessPlayerAction(ctx, state, "n"); err != nil {
+		t.Fatal(err)
+	}
+	courtyard := state.World.Current()
+	if courtyard.Name != "Courtyard" || courtyard.Description != "A weedy courtyard." || !courtyard.Visited {
+		t.Errorf("Expected to arrive in the generated courtyard, got %+v", courtyard)
+	}
+	if courtyard.exit("south") == nil || courtyard.exit("east") == nil || state.World.Location("Stables") == nil {
+		t.Errorf("Expected exits back to the gate and on to the stables, got %+v", courtyard.Exits)
+	}
+	if len(provider.requests) != 2 || provider.requests[0].Task != "world_building" {
+		t.Errorf("Expected location generation before narration, got %d requests", len(provider.requests))
+	}
+	if state.Turn != 1 {
+		t.Errorf("Expected the move to take a turn, got %d", state.Turn)
+	}
+
+	// Known locations are not generated again
+	if err := engine.ProcessPlayerAction(ctx, state, "go to the gate"); err != nil {
+		t.Fatal(err)
+	}
+	if state.World.CurrentLocation != "Gate" || len(provider.requests) != 3 {
+		t.Errorf("Expected a narrated move back to the gate, got %s after %d requests",
+			state.World.CurrentLocation, len(provider.requests))
+	}
+}
+
+func TestNavigateOpensNewLocations(t *testing.T) {
+	engine, _ := newReplyEngine(t,
+		`{"name": "Old Mill", "description": "A mill with a broken wheel."}`,
+		"You follow the stream to a mill.\n---STATE---\n{}",
+	)
+	state := NewGameState()
+	state.World.moveTo(state.World.addLocation("Clearing", "A quiet clearing."))
+
+	if err := engine.ProcessPlayerAction(context.Background(), state, "go northeast"); err != nil {
+		t.Fatal(err)
+	}
+	mill := state.World.Current()
+	if mill == nil || mill.Name != "Old Mill" || !mill.Visited {
+		t.Fatalf("Expected to arrive at the generated mill, got %+v", mill)
+	}
+	if back := mill.exit("southwest"); back == nil || back.To != "Clearing" {
+		t.Errorf("Expected a way back to the clearing, got %+v", mill.Exits)
+	}
+}
+
+func TestExploreKeepsKnownDescriptions(t *testing.T) {
+	engine, _ := newReplyEngine(t,
+		`{"name": "Gate", "description": "A rewritten gate."}`,
+		"You wander back to the gate.\n---STATE---\n{}",
+	)
+	state := NewGameState()
+	gate := state.World.addLocation("Gate", "An iron gate.")
+	state.World.moveTo(state.World.addLocation("Clearing", "A quiet clearing."))
+
+	if err := engine.ProcessPlayerAction(context.Background(), state, "go south"); err != nil {
+		t.Fatal(err)
+	}
+	if state.World.Current() != gate || gate.Description != "An iron gate." {
+		t.Errorf("Expected to reach the gate as the player saw it, got %+v", state.World.Current())
+	}
+}
@@ -0,0 +1,194 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"axon/internal/logger"
+)
+
+const (
+	// Number of unsummarized history entries that triggers summarization
+	summarizeThreshold = 30
+	// Number of recent entries left out of a summary so the model still sees them verbatim
+	summarizeKeepRecent = 10
+	// Most facts injected into a prompt, newest first
+	maxPromptFacts = 30
+)
+
+// Memory is the long-term memory of a game: a rolling summary of older
+// history plus facts about named people, places and quests
+type Memory struct {
+	// Summary is the story so far, covering History[:SummarizedThrough]
+	Summary           string `json:"summary"`
+	SummarizedThrough int    `json:"summarized_through"`
+	// Facts are keyed by kind and name, see factKey
+	Facts map[string]Fact `json:"facts"`
+}
+
+// Fact is something the narrator must not forget
+type Fact struct {
+	// Kind is "npc", "place", "quest" or "other"
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Detail string `json:"detail"`
+	// Turn is when the fact was last updated
+	Turn int `json:"turn"`
+}
+
+// factKey identifies a fact so later summaries update it rather than duplicate it
+func factKey(kind, name string) string {
+	return strings.ToLower(kind) + ":" + strings.ToLower(name)
+}
+
+// RememberFact adds or updates a fact
+func (m *Memory) RememberFact(fact Fact) {
+	if m.Facts == nil {
+		m.Facts = make(map[string]Fact)
+	}
+	m.Facts[factKey(fact.Kind, fact.Name)] = fact
+}
+
+// promptLines renders the memory for inclusion in a prompt context
+func (m *Memory) promptLines() []string {
+	var lines []string
+	if m.Summary != "" {
+		lines = append(lines, "Story so far: "+m.Summary)
+	}
+
+	facts := make([]Fact, 0, len(m.Facts))
+	for _, fact := range m.Facts {
+		facts = append(facts, fact)
+	}
+	sort.Slice(facts, func(i, j int) bool {
+		if facts[i].Turn != facts[j].Turn {
+			return facts[i].Turn > facts[j].Turn
+		}
+		return factKey(facts[i].Kind, facts[i].Name) < factKey(facts[j].Kind, facts[j].Name)
+	})
+	if len(facts) > maxPromptFacts {
+		facts = facts[:maxPromptFacts]
+	}
+	if len(facts) > 0 {
+		lines = append(lines, "Known facts:")
+		for _, fact := range facts {
+			lines = append(lines, fmt.Sprintf("- %s %s: %s", fact.Kind, fact.Name, fact.Detail))
+		}
+	}
+	return lines
+}
+
+// unsummarizedHistory returns the history entries not yet covered by the summary
+func (gs *GameState) unsummarizedHistory() []HistoryEntry {
+	through := gs.Memory.SummarizedThrough
+	if through < 0 || through > len(gs.History) {
+		through = 0
+	}
+	return gs.History[through:]
+}
+
+// summarySchemaPrompt describes the JSON the summarization model must return
+const summarySchemaPrompt = `Respond with a single JSON object and nothing else, using this shape:
+{
+  "summary": "the story so far in at most 150 words, merging the previous summary with the new events",
+  "facts": [{"kind": "npc, place, quest or other", "name": "name", "detail": "one sentence worth remembering"}]
+}
+Only list facts that are new or have changed.`
+
+// summarySpec is the structured reply of the summarization model
+type summarySpec struct {
+	Summary string `json:"summary"`
+	Facts   []Fact `json:"facts"`
+}
+
+// parseSummarySpec decodes and validates a summary JSON object
+func parseSummarySpec(raw []byte) (*summarySpec, error) {
+	var spec summarySpec
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("malformed summary JSON: %w", err)
+	}
+	spec.Summary = strings.TrimSpace(spec.Summary)
+	if spec.Summary == "" {
+		return nil, fmt.Errorf("summary is empty")
+	}
+
+	facts := make([]Fact, 0, len(spec.Facts))
+	for _, fact := range spec.Facts {
+		fact.Kind = strings.ToLower(strings.TrimSpace(fact.Kind))
+		fact.Name = strings.TrimSpace(fact.Name)
+		fact.Detail = strings.TrimSpace(fact.Detail)
+		if fact.Name == "" || fact.Detail == "" {
+			continue
+		}
+		switch fact.Kind {
+		case "npc", "place", "quest":
+		default:
+			fact.Kind = "other"
+		}
+		facts = append(facts, fact)
+	}
+	spec.Facts = facts
+	return &spec, nil
+}
+
+// summaryDue reports whether enough unsummarized history has built up to
+// fold into the story summary
+func (gs *GameState) summaryDue() bool {
+	return len(gs.unsummarizedHistory()) >= summarizeThreshold
+}
+
+// UpdateMemory folds older history into the story summary once enough
+// unsummarized entries have built up. The most recent entries are left out
+// of the summary so prompts still quote them verbatim. Failures leave the
+// memory unchanged and are retried on a later turn.
+func (e *Engine) UpdateMemory(ctx context.Context, state *GameState) error {
+	if !state.summaryDue() {
+		return nil
+	}
+	pending := state.unsummarizedHistory()
+	fold := pending[:len(pending)-summarizeKeepRecent]
+
+	lines := make([]string, 0, len(fold))
+	for _, entry := range fold {
+		lines = append(lines, formatHistoryEntry(entry))
+	}
+
+	promptContext := []string{
+		"You maintain the long-term memory of a text-based adventure game.",
+		fmt.Sprintf("World: %s - %s", state.World.Name, state.World.Description),
+	}
+	if state.Memory.Summary != "" {
+		promptContext = append(promptContext, "Previous summary: "+state.Memory.Summary)
+	}
+	promptContext = append(promptContext, summarySchemaPrompt)
+
+	req := e.taskRequest("summarization")
+	req.Prompt = "New events to fold into the summary:\n" + strings.Join(lines, "\n")
+	req.Context = promptContext
+
+	taskCtx, cancel := e.taskContext(ctx, "summarization")
+	defer cancel()
+
+	var spec *summarySpec
+	err := e.generateJSON(taskCtx, state, req, func(raw []byte) error {
+		parsed, err := parseSummarySpec(raw)
+		spec = parsed
+		return err
+	})
+	if err != nil {
+		logger.Error("Story summarization failed: %v", err)
+		return err
+	}
+
+	state.Memory.Summary = spec.Summary
+	state.Memory.SummarizedThrough = len(state.History) - len(pending) + len(fold)
+	for _, fact := range spec.Facts {
+		fact.Turn = state.Turn
+		state.Memory.RememberFact(fact)
+	}
+	logger.Info("Summarized %d history entries, %d facts remembered", len(fold), len(state.Memory.Facts))
+	return nil
+}
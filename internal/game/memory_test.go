@@ -0,0 +1,129 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const summaryJSON = `{
+  "summary": "Mara promised the ferryman Oskar a silver coin for passage to the Sunken Chapel.",
+  "facts": [
+    {"kind": "NPC", "name": "Oskar", "detail": "Ferryman owed a silver coin"},
+    {"kind": "rumor", "name": "Bell", "detail": "A bell rings beneath the waves"},
+    {"kind": "place", "name": "", "detail": "nameless"}
+  ]
+}`
+
+// fillHistory adds n alternating player and narrator entries
+func fillHistory(state *GameState, n int) {
+	for i := 0; i < n; i++ {
+		entryType := entryTypePlayer
+		if i%2 == 1 {
+			entryType = entryTypeNarrator
+		}
+		state.AddHistoryEntry(entryType, fmt.Sprintf("event %d", i))
+	}
+}
+
+func TestUpdateMemoryBelowThreshold(t *testing.T) {
+	engine, provider := newReplyEngine(t, summaryJSON)
+	state := NewGameState()
+	fillHistory(state, summarizeThreshold-1)
+
+	if err := engine.UpdateMemory(context.Background(), state); err != nil {
+		t.Fatalf("UpdateMemory returned error: %v", err)
+	}
+	if len(provider.requests) != 0 || state.Memory.Summary != "" {
+		t.Error("Memory should not be summarized below the threshold")
+	}
+}
+
+func TestUpdateMemoryFoldsOlderHistory(t *testing.T) {
+	engine, provider := newReplyEngine(t, summaryJSON)
+	state := NewGameState()
+	fillHistory(state, summarizeThreshold+5)
+
+	if err := engine.UpdateMemory(context.Background(), state); err != nil {
+		t.Fatalf("UpdateMemory returned error: %v", err)
+	}
+
+	folded := summarizeThreshold + 5 - summarizeKeepRecent
+	if state.Memory.SummarizedThrough != folded {
+		t.Errorf("Expected %d entries summarized, got %d", folded, state.Memory.SummarizedThrough)
+	}
+	if !strings.Contains(provider.requests[0].Prompt, "event 0") ||
+		strings.Contains(provider.requests[0].Prompt, fmt.Sprintf("event %d", folded)) {
+		t.Errorf("Only entries outside the recent window should be summarized: %s", provider.requests[0].Prompt)
+	}
+	if !strings.Contains(state.Memory.Summary, "Oskar") {
+		t.Errorf("Unexpected summary %q", state.Memory.Summary)
+	}
+
+	oskar, ok := state.Memory.Facts[factKey("npc", "oskar")]
+	if !ok || oskar.Name != "Oskar" {
+		t.Errorf("Expected NPC fact for Oskar, got %+v", state.Memory.Facts)
+	}
+	if bell := state.Memory.Facts[factKey("other", "Bell")]; bell.Kind != "other" {
+		t.Errorf("Unknown kinds should become other, got %+v", bell)
+	}
+	if len(state.Memory.Facts) != 2 {
+		t.Errorf("Nameless facts should be dropped, got %d facts", len(state.Memory.Facts))
+	}
+	if len(state.unsummarizedHistory()) != summarizeKeepRecent {
+		t.Errorf("Expected %d unsummarized entries, got %d", summarizeKeepRecent, len(state.unsummarizedHistory()))
+	}
+}
+
+func TestUpdateMemoryFailureKeepsHistory(t *testing.T) {
+	engine, _ := newReplyEngine(t, "I cannot summarize that.")
+	state := NewGameState()
+	fillHistory(state, summarizeThreshold)
+
+	if err := engine.UpdateMemory(context.Background(), state); err == nil {
+		t.Error("Expected error for unusable summary")
+	}
+	if state.Memory.SummarizedThrough != 0 || state.Memory.Summary != "" {
+		t.Errorf("Failed summarization should leave memory unchanged: %+v", state.Memory)
+	}
+}
+
+func TestMemoryInjectedIntoPrompts(t *testing.T) {
+	engine, provider := newReplyEngine(t, "The ferryman nods.", summaryJSON)
+	state := NewGameState()
+	fillHistory(state, summarizeThreshold+1)
+
+	// The action triggers summarization once the turn is narrated
+	if err := engine.ProcessPlayerAction(context.Background(), state, "pay the ferryman"); err != nil {
+		t.Fatalf("ProcessPlayerAction returned error: %v", err)
+	}
+	if len(provider.requests) != 2 || provider.requests[1].Task != "summarization" {
+		t.Fatalf("Expected narration then summarization requests, got %d", len(provider.requests))
+	}
+
+	if err := engine.ProcessPlayerAction(context.Background(), state, "board the ferry"); err != nil {
+		t.Fatalf("ProcessPlayerAction returned error: %v", err)
+	}
+	sent := strings.Join(provider.requests[2].Context, "\n")
+	if !strings.Contains(sent, "Story so far: Mara promised") || !strings.Contains(sent, "- npc Oskar: Ferryman owed") {
+		t.Errorf("Prompt should include memory:\n%s", sent)
+	}
+	if strings.Contains(sent, "event 0") {
+		t.Error("Summarized entries should not be repeated verbatim")
+	}
+}
+
+func TestMemorySurvivesClone(t *testing.T) {
+	state := NewGameState()
+	state.Memory.Summary = "A storm is coming."
+	state.Memory.RememberFact(Fact{Kind: "place", Name: "Lighthouse", Detail: "Abandoned"})
+
+	clone, err := state.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Memory.Summary != state.Memory.Summary || len(clone.Memory.Facts) != 1 {
+		t.Errorf("Memory not preserved: %+v", clone.Memory)
+	}
+}
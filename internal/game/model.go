@@ -1,8 +1,11 @@
 package game
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -24,6 +27,13 @@ const (
 	ModeSaveLoad
 )
 
+const (
+	// Narrowest terminal the map panel is shown on
+	mapPanelMinTerminalWidth = 60
+	// Widest the map panel grows, however wide the terminal
+	mapPanelMaxWidth = 30
+)
+
 // Model represents the main game model for Bubble Tea
 type Model struct {
 	// Configuration
@@ -44,12 +54,35 @@ type Model struct {
 	scrollOffset int
 	width        int
 	height       int
+	// Map side panel, toggled with Tab
+	showMap bool
 	// Action suggestions
 	suggestions []string
 	// Error message
 	errorMessage string
+	// Lifetime context, cancelled when the game quits
+	ctx  context.Context
+	stop context.CancelFunc
+	// Cancels the in-flight engine request, if any
+	cancel context.CancelFunc
 	// Loading state
-	isLoading bool
+	isLoading    bool
+	requestID    int
+	spinnerFrame int
+	loadingSince time.Time
+	// In-flight action: the submitted input and narration streamed so far
+	pendingAction string
+	streamText    string
+	// Request whose suggestions were started as soon as its narration was complete
+	suggestionsFor int
+	// Usage and story memory reported while a request was in flight, held
+	// until its result settles which game state is live
+	heldUsage  UsageStats
+	heldMemory *memoryMsg
+	// Whether older history is being summarized in the background
+	summarizing bool
+	// Wrapped history lines, shared by every copy of the model
+	history *historyView
 }
 
 // NewModel creates a new game model
@@ -67,7 +100,11 @@ func NewModel(cfg *config.Config, termInfo *terminal.TerminalInfo) *Model {
 		logger.Debug("Using standard terminal styles")
 	}
 
+	ctx, stop := context.WithCancel(context.Background())
+
 	return &Model{
+		ctx:          ctx,
+		stop:         stop,
 		config:       cfg,
 		terminalInfo: termInfo,
 		styles:       styles,
@@ -77,6 +114,8 @@ func NewModel(cfg *config.Config, termInfo *terminal.TerminalInfo) *Model {
 		mode:         ModeMainMenu,
 		width:        cfg.Terminal.Width,
 		height:       cfg.Terminal.Height,
+		showMap:      cfg.Terminal.MapPanel,
+		history:      &historyView{},
 	}
 }
 
@@ -95,6 +134,59 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
+
+	case narrationChunkMsg:
+		// Keep draining cancelled streams so their goroutines can finish
+		if msg.id == m.requestID && m.isLoading {
+			m.streamText += msg.text
+		}
+		return m, waitForActionMsg(msg.ch)
+
+	case turnReadyMsg:
+		if msg.id != m.requestID || !m.isLoading {
+			return m, waitForActionMsg(msg.ch)
+		}
+		// Suggestions only need the narration, so they start while the rest of the turn is applied
+		m.suggestionsFor = msg.id
+		return m, tea.Batch(waitForActionMsg(msg.ch), m.runSuggestions(msg.ctx, msg.id, msg.state))
+
+	case actionResultMsg:
+		if msg.id != m.requestID || !m.isLoading {
+			// The tokens were paid for even though the turn is thrown away
+			logger.Debug("Dropping result of cancelled action %d", msg.id)
+			m.keepUsage(msg.spent)
+			return m, nil
+		}
+		return m.handleActionResult(msg)
+
+	case worldResultMsg:
+		if msg.id != m.requestID || !m.isLoading {
+			logger.Debug("Dropping result of cancelled world setup %d", msg.id)
+			return m, nil
+		}
+		return m.handleWorldResult(msg)
+
+	case suggestionsMsg:
+		m.keepUsage(msg.usage)
+		if msg.id == m.requestID && msg.suggestions != nil {
+			m.suggestions = msg.suggestions
+		}
+		return m, nil
+
+	case memoryMsg:
+		if m.isLoading {
+			m.heldMemory = &msg
+			return m, nil
+		}
+		m.applyMemory(msg)
+		return m, nil
+
+	case spinnerTickMsg:
+		if msg.id != m.requestID || !m.isLoading {
+			return m, nil
+		}
+		m.spinnerFrame = (m.spinnerFrame + 1) % len(spinnerFrames)
+		return m, tickSpinner(msg.id)
 	}
 
 	return m, nil
@@ -104,12 +196,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c", "q":
+		if msg.String() == "q" && (m.mode == ModePlaying || m.mode == ModeWorldSetup) {
+			// Typed text may contain q; the quit command leaves play instead
+			m.inputValue += "q"
+			m.errorMessage = ""
+			return m, nil
+		}
 		if m.mode == ModePlaying {
 			// Allow quitting from game with confirmation
 			m.mode = ModeMainMenu
+			if m.isLoading {
+				return m.cancelRequest()
+			}
 			return m, nil
 		}
-		return m, tea.Quit
+		return m.quit()
+
+	case "esc":
+		return m.cancelRequest()
 
 	case "enter":
 		return m.handleEnter()
@@ -130,6 +234,12 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.scrollOffset++
 		return m, nil
 
+	case "tab":
+		if m.mode == ModePlaying {
+			m.showMap = !m.showMap
+		}
+		return m, nil
+
 	default:
 		// Add character to input
 		if len(msg.String()) == 1 {
@@ -171,7 +281,7 @@ func (m Model) handleMainMenuSelection() (tea.Model, tea.Cmd) {
 	case "3", "settings":
 		m.mode = ModeSettings
 	case "4", "quit", "exit":
-		return m, tea.Quit
+		return m.quit()
 	default:
 		m.errorMessage = "Invalid selection. Choose 1-4."
 	}
@@ -191,31 +301,116 @@ func (m Model) handleWorldSetup() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Initialize world with AI
-	logger.Info("Starting world initialization process")
-	m.isLoading = true
-	err := m.engine.InitializeWorld(m.gameState, input)
+	if m.isLoading {
+		logger.Debug("Ignoring world setup while another request is in flight")
+		m.inputValue = input
+		return m, nil
+	}
+
+	// Initialize world with AI in the background
+	ctx, spinner := m.beginRequest()
+	return m, tea.Batch(m.runWorldSetup(ctx, m.requestID, NewGameState(), input), spinner)
+}
+
+// handleWorldResult applies a generated world and starts play
+func (m Model) handleWorldResult(msg worldResultMsg) (tea.Model, tea.Cmd) {
 	m.isLoading = false
-	logger.Info("World initialization process completed")
 
-	if err != nil {
-		logger.Error("World initialization failed: %v", err)
-		m.errorMessage = fmt.Sprintf("Error creating world: %v", err)
+	if msg.err != nil {
+		logger.Error("World initialization failed: %v", msg.err)
+		m.errorMessage = "Error creating world: " + describeAIError(msg.err)
+		m.settleHeld()
 		return m, nil
 	}
-
-	// Generate initial action suggestions
-	logger.Info("Generating initial action suggestions")
-	suggestions, _ := m.engine.GenerateActionSuggestions(m.gameState)
-	m.suggestions = suggestions
-	logger.Debug("Generated suggestions: %v", suggestions)
+	m.gameState = msg.state
+	m.settleHeld()
 
 	m.mode = ModePlaying
 	m.scrollOffset = 0
+	m.suggestions = nil
 	logger.Info("Switched to playing mode")
+
+	// Generate initial action suggestions
+	logger.Info("Generating initial action suggestions")
+	return m, m.suggestNext()
+}
+
+// beginRequest marks a new engine request as in flight, returning its context and the spinner command
+func (m *Model) beginRequest() (context.Context, tea.Cmd) {
+	ctx := m.newRequestContext()
+	m.requestID++
+	m.isLoading = true
+	m.spinnerFrame = 0
+	m.loadingSince = time.Now()
+	return ctx, tickSpinner(m.requestID)
+}
+
+// newRequestContext cancels any previous request and returns a context for the next one
+func (m *Model) newRequestContext() context.Context {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if m.ctx == nil {
+		m.ctx, m.stop = context.WithCancel(context.Background())
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancel = cancel
+	return ctx
+}
+
+// quit cancels all outstanding engine work and exits the program
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	logger.Info("Shutting down, cancelling outstanding requests")
+	if m.stop != nil {
+		m.stop()
+	}
+	return m, tea.Quit
+}
+
+// cancelRequest abandons the in-flight engine request, if any
+func (m Model) cancelRequest() (tea.Model, tea.Cmd) {
+	if !m.isLoading {
+		return m, nil
+	}
+
+	logger.Info("Cancelling in-flight request %d", m.requestID)
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	// Bumping the id makes any late result look stale
+	m.requestID++
+	m.isLoading = false
+	m.settleHeld()
+
+	switch m.mode {
+	case ModePlaying:
+		m.inputValue = m.pendingAction
+		m.gameState.AddHistoryEntry(entryTypeSystem, "Action cancelled.")
+	case ModeWorldSetup:
+		m.errorMessage = "World creation cancelled."
+	}
+	m.pendingAction = ""
+	m.streamText = ""
 	return m, nil
 }
 
+// loadingStatus renders the spinner line shown while a request is in flight,
+// noting when requests are held back by a rate limit
+func (m Model) loadingStatus(label string) string {
+	elapsed := int(time.Since(m.loadingSince).Seconds())
+	if m.engine != nil {
+		queue := m.engine.QueueStatus()
+		switch {
+		case queue.Wait > 0:
+			label += fmt.Sprintf(" - rate limited, waiting %ds", int(math.Ceil(queue.Wait.Seconds())))
+		case queue.Queued > 0:
+			label += " - queued behind other requests"
+		}
+	}
+	return fmt.Sprintf("%s %s (%ds, Esc to cancel)", spinnerFrames[m.spinnerFrame], label, elapsed)
+}
+
 // handleGameAction handles game actions during play
 func (m Model) handleGameAction() (tea.Model, tea.Cmd) {
 	input := strings.TrimSpace(m.inputValue)
@@ -227,31 +422,31 @@ func (m Model) handleGameAction() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.isLoading {
+		logger.Debug("Ignoring action while another request is in flight")
+		m.inputValue = input
+		return m, nil
+	}
+
 	// Handle special commands
-	if strings.HasPrefix(strings.ToLower(input), "save") {
-		parts := strings.SplitN(input, " ", 2)
-		saveName := ""
-		if len(parts) > 1 {
-			saveName = parts[1]
-		}
-		err := m.storage.SaveGame(saveName, m.gameState)
+	cmd := ParseCommand(input)
+	switch cmd.Verb {
+	case "save":
+		err := m.storage.SaveGame(cmd.Args, m.gameState)
 		if err != nil {
 			m.errorMessage = fmt.Sprintf("Error saving game: %v", err)
 		} else {
 			m.gameState.AddHistoryEntry(entryTypeSystem, "Game saved successfully.")
 		}
 		return m, nil
-	}
 
-	if strings.HasPrefix(strings.ToLower(input), "load") {
-		parts := strings.SplitN(input, " ", 2)
-		if len(parts) < 2 {
+	case "load":
+		if cmd.Args == "" {
 			m.errorMessage = "Please specify a save name."
 			return m, nil
 		}
-		saveName := parts[1]
 		var loadedState GameState
-		err := m.storage.LoadGame(saveName, &loadedState)
+		err := m.storage.LoadGame(cmd.Args, &loadedState)
 		if err != nil {
 			m.errorMessage = fmt.Sprintf("Error loading game: %v", err)
 		} else {
@@ -259,31 +454,167 @@ func (m Model) handleGameAction() (tea.Model, tea.Cmd) {
 			m.gameState.AddHistoryEntry(entryTypeSystem, "Game loaded successfully.")
 		}
 		return m, nil
+
+	case "quit":
+		m.mode = ModeMainMenu
+		return m, nil
+
+	case "usage":
+		if cmd.Args == "" {
+			// Answered here rather than by the engine, whose snapshot would miss
+			// usage reported since, or still held for an in-flight request
+			usage := m.currentUsage()
+			m.gameState.AddHistoryEntry(entryTypePlayer, input)
+			m.gameState.AddHistoryEntry(entryTypeSystem, strings.Join(usageLines(&usage), "\n"))
+			m.scrollOffset = -1
+			return m, nil
+		}
 	}
 
-	// Process normal game action
+	// Process normal game action on a copy, so the live state is never shared
+	// with the background work
 	logger.Info("Processing normal game action")
-	m.isLoading = true
-	err := m.engine.ProcessPlayerAction(m.gameState, input)
+	snapshot, err := m.gameState.Clone()
+	if err != nil {
+		logger.Error("Game action not started: %v", err)
+		m.errorMessage = "Error processing action: " + err.Error()
+		m.inputValue = input
+		return m, nil
+	}
+	ctx, spinner := m.beginRequest()
+	m.pendingAction = input
+	m.streamText = ""
+	if !isEngineCommand(cmd) {
+		// Built-in commands leave the turn as it was, so its suggestions still apply
+		m.suggestions = nil
+	}
+
+	// Auto-scroll to show the streamed narration as it arrives
+	m.scrollOffset = -1
+
+	ch := make(chan tea.Msg, 64)
+	return m, tea.Batch(m.runAction(ctx, m.requestID, snapshot, input, ch), spinner)
+}
+
+// handleActionResult applies the outcome of a processed player action
+func (m Model) handleActionResult(msg actionResultMsg) (tea.Model, tea.Cmd) {
 	m.isLoading = false
+	m.pendingAction = ""
+	m.streamText = ""
 	logger.Info("Game action processing completed")
 
-	if err != nil {
-		logger.Error("Game action processing failed: %v", err)
-		m.errorMessage = fmt.Sprintf("Error processing action: %v", err)
+	if msg.err != nil {
+		logger.Error("Game action processing failed: %v", msg.err)
+		m.errorMessage = "Error processing action: " + describeAIError(msg.err)
+		m.settleHeld()
 		return m, nil
 	}
-
-	// Generate new action suggestions
-	logger.Debug("Generating new action suggestions")
-	suggestions, _ := m.engine.GenerateActionSuggestions(m.gameState)
-	m.suggestions = suggestions
-	logger.Debug("New suggestions: %v", suggestions)
+	m.gameState = msg.state
+	m.settleHeld()
 
 	// Auto-scroll to show latest entries
 	m.scrollOffset = -1 // Use -1 to indicate we want to show the latest
 
-	return m, nil
+	if msg.builtin {
+		// Nothing happened in the story, so there is nothing new to suggest or summarize
+		return m, nil
+	}
+
+	// Suggestions normally started when the narration was complete; turns
+	// without narration, such as blocked moves, start them now
+	var suggest tea.Cmd
+	if m.suggestionsFor != msg.id {
+		logger.Debug("Generating new action suggestions")
+		suggest = m.suggestNext()
+	}
+	return m, tea.Batch(suggest, m.maintainMemory())
+}
+
+// keepUsage adds usage reported by background work to the game. While a
+// request is in flight the live state is about to be replaced by its
+// result, so the usage is held until then.
+func (m *Model) keepUsage(usage UsageStats) {
+	if m.isLoading {
+		m.heldUsage.Merge(usage)
+		return
+	}
+	if m.gameState != nil {
+		m.gameState.Usage.Merge(usage)
+	}
+}
+
+// currentUsage returns the game's usage, including any held for an in-flight request
+func (m Model) currentUsage() UsageStats {
+	var usage UsageStats
+	usage.Merge(m.gameState.Usage)
+	usage.Merge(m.heldUsage)
+	return usage
+}
+
+// settleHeld folds the usage and memory held during a request into the live game state
+func (m *Model) settleHeld() {
+	m.gameState.Usage.Merge(m.heldUsage)
+	m.heldUsage = UsageStats{}
+	if held := m.heldMemory; held != nil {
+		m.heldMemory = nil
+		m.applyMemory(*held)
+	}
+}
+
+// maintainMemory starts summarizing older history in the background once
+// enough has built up. History is only archived while no summary is being
+// made, since archiving shifts the entries a summary covers.
+func (m *Model) maintainMemory() tea.Cmd {
+	if m.summarizing {
+		return nil
+	}
+	if !m.gameState.summaryDue() {
+		m.compactHistory()
+		return nil
+	}
+	snapshot, err := m.gameState.Clone()
+	if err != nil {
+		logger.Error("Skipping memory upkeep: %v", err)
+		return nil
+	}
+	if m.ctx == nil {
+		m.ctx, m.stop = context.WithCancel(context.Background())
+	}
+	m.summarizing = true
+	// Upkeep outlives the request that started it and stops only when the game quits
+	return m.runMemoryUpkeep(m.ctx, snapshot)
+}
+
+// applyMemory adopts a story summary made in the background, then archives
+// history past the limit. A summary of another game, started or loaded
+// since, is dropped.
+func (m *Model) applyMemory(msg memoryMsg) {
+	m.summarizing = false
+	m.gameState.Usage.Merge(msg.usage)
+	if !msg.game.Equal(m.gameState.CreatedAt) || msg.memory.SummarizedThrough > len(m.gameState.History) {
+		logger.Debug("Dropping story memory summarized from another game")
+		return
+	}
+	m.gameState.Memory = msg.memory
+	m.compactHistory()
+}
+
+// compactHistory archives live history past the configured limit
+func (m *Model) compactHistory() {
+	if archived := m.gameState.CompactHistory(m.config.Game.HistoryLimit); archived > 0 {
+		logger.Info("Archived %d history entries (%d archived in total)", archived, len(m.gameState.Archive))
+	}
+}
+
+// suggestNext starts suggestion generation from a copy of the current game
+// state. Suggestions are skipped if the state cannot be copied.
+func (m *Model) suggestNext() tea.Cmd {
+	snapshot, err := m.gameState.Clone()
+	if err != nil {
+		logger.Error("Skipping suggestions: %v", err)
+		return nil
+	}
+	return m.runSuggestions(m.newRequestContext(), m.requestID, snapshot)
 }
 
 // View renders the current view
@@ -336,7 +667,7 @@ Be creative! Examples:
 Your world: %s`, m.inputValue)
 
 	if m.isLoading {
-		setup += "\n\nCreating world..."
+		setup += "\n\n" + m.loadingStatus("Creating world...")
 	}
 
 	if m.errorMessage != "" {
@@ -352,8 +683,11 @@ func (m Model) renderGame() string {
 	inputHeight := 4 // Space for input panel
 	historyHeight := m.height - inputHeight
 
-	// Render history panel
+	// Render history panel, beside the map when it is shown
 	historyContent := m.renderHistory(historyHeight)
+	if panelWidth := m.mapPanelWidth(); panelWidth > 0 {
+		historyContent = m.renderHistoryWithMap(historyHeight, panelWidth)
+	}
 
 	// Render input panel
 	inputContent := m.renderInput()
@@ -370,9 +704,51 @@ func (m Model) renderGame() string {
 	return m.terminalInfo.FormatForTerminal(finalContent)
 }
 
+// mapPanelWidth returns the width of the map side panel, or zero when it is
+// hidden or the terminal is too narrow for it
+func (m Model) mapPanelWidth() int {
+	if !m.showMap || m.width < mapPanelMinTerminalWidth {
+		return 0
+	}
+	return min(m.width/3, mapPanelMaxWidth)
+}
+
+// historyWidth returns the width of the history panel, which narrows to
+// make room for the map panel when it is shown
+func (m Model) historyWidth() int {
+	if panelWidth := m.mapPanelWidth(); panelWidth > 0 {
+		return m.width - panelWidth - 3
+	}
+	return m.width
+}
+
+// renderHistoryWithMap renders the game history with the map panel to its
+// right, divided by a column of '|' so it displays on every terminal type
+func (m Model) renderHistoryWithMap(height, panelWidth int) string {
+	narrow := m
+	narrow.width = m.historyWidth()
+	history := strings.Split(narrow.renderHistory(height), "\n")
+	// renderHistory leaves two lines spare, one of which holds the panel title
+	panel := append([]string{"MAP"}, renderMap(m.gameState.World, panelWidth, height-3)...)
+
+	lines := make([]string, max(len(history), len(panel)))
+	for i := range lines {
+		var left, right string
+		if i < len(history) {
+			left = history[i]
+		}
+		if i < len(panel) {
+			right = panel[i]
+		}
+		padding := max(narrow.width-len([]rune(left)), 0)
+		lines[i] = strings.TrimRight(left+strings.Repeat(" ", padding)+" | "+right, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
 // renderHistory renders the game history
 func (m Model) renderHistory(height int) string {
-	if len(m.gameState.History) == 0 {
+	if len(m.gameState.History) == 0 && m.pendingAction == "" {
 		return m.wrapText("Welcome to Axon! Your adventure begins...")
 	}
 
@@ -382,19 +758,22 @@ func (m Model) renderHistory(height int) string {
 
 	// Build display lines with simple ASCII formatting and text wrapping
 	var lines []string
-	for _, entry := range history {
-		var formattedContent string
-		switch entry.Type {
-		case entryTypePlayer:
-			formattedContent = "> " + entry.Content
-		case entryTypeNarrator:
-			formattedContent = entry.Content
-		case entryTypeSystem:
-			formattedContent = "[System] " + entry.Content
+	if m.history != nil {
+		lines = m.history.linesFor(history, m.width, m.formatHistoryEntry)
+	} else {
+		for _, entry := range history {
+			lines = append(lines, m.formatHistoryEntry(entry)...)
 		}
-		// Wrap text to terminal width
-		wrappedLines := m.wrapTextToLines(formattedContent)
-		lines = append(lines, wrappedLines...)
+	}
+
+	// Copy before appending the in-flight lines so the cached slice stays untouched
+	if m.pendingAction != "" {
+		lines = append(lines[:len(lines):len(lines)], m.wrapTextToLines("> "+m.pendingAction)...)
+	}
+
+	// Show any narration streamed so far for the in-flight action
+	if m.pendingAction != "" && m.streamText != "" {
+		lines = append(lines, m.wrapTextToLines(m.streamText)...)
 	}
 
 	// Handle scrolling - show most recent entries by default
@@ -434,7 +813,7 @@ func (m Model) renderInput() string {
 	content.WriteString("> " + m.inputValue)
 
 	if m.isLoading {
-		content.WriteString("\nProcessing...")
+		content.WriteString("\n" + m.loadingStatus("Processing..."))
 	}
 
 	if m.errorMessage != "" {
@@ -474,6 +853,35 @@ func (m Model) wrapText(text string) string {
 	return m.wrapTextToWidth(text, m.width-2) // Leave 2 chars for padding
 }
 
+// formatHistoryEntry renders a history entry as wrapped display lines
+func (m Model) formatHistoryEntry(entry HistoryEntry) []string {
+	var formattedContent string
+	switch entry.Type {
+	case entryTypePlayer:
+		formattedContent = "> " + entry.Content
+	case entryTypeNarrator:
+		formattedContent = entry.Content
+	case entryTypeSystem:
+		formattedContent = "[System] " + entry.Content
+	}
+	// Wrap each line to terminal width. Lines that already fit are kept as
+	// they are, so lists keep their layout, and preformatted lines are cut
+	// rather than wrapped, so maps keep their columns.
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(formattedContent, "\n"), "\n") {
+		runes := []rune(line)
+		switch {
+		case m.width <= 0 || len(runes) <= m.width-2:
+			lines = append(lines, strings.TrimRight(line, " "))
+		case entry.Preformatted:
+			lines = append(lines, strings.TrimRight(string(runes[:max(m.width-2, 0)]), " "))
+		default:
+			lines = append(lines, m.wrapTextToLines(line)...)
+		}
+	}
+	return lines
+}
+
 // wrapTextToLines wraps text and returns as slice of lines
 func (m Model) wrapTextToLines(text string) []string {
 	if m.width <= 0 {
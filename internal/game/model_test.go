@@ -1,8 +1,10 @@
 package game
 
 import (
+	"math"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -357,3 +359,482 @@ func TestModelLoadingState(t *testing.T) {
 		t.Error("View should show processing message when loading in game")
 	}
 }
+
+// awaitMsg runs cmd, following batches and streamed chunks, until a message
+// of the wanted kind is produced. Spinner ticks are skipped.
+func awaitMsg(t *testing.T, cmd tea.Cmd, want func(tea.Msg) bool) tea.Msg {
+	t.Helper()
+	queue := []tea.Cmd{cmd}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if next == nil {
+			continue
+		}
+		msg := next()
+		if want(msg) {
+			return msg
+		}
+		switch msg := msg.(type) {
+		case tea.BatchMsg:
+			queue = append(queue, msg...)
+		case narrationChunkMsg:
+			queue = append(queue, waitForActionMsg(msg.ch))
+		}
+	}
+	t.Fatal("Command finished without producing the expected message")
+	return nil
+}
+
+func TestModelStreamedAction(t *testing.T) {
+	cfg := &config.Config{
+		Terminal: config.TerminalConfig{
+			Width:  80,
+			Height: 24,
+		},
+	}
+	model := NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.inputValue = "look around"
+
+	newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Submitting an action should return a command")
+	}
+
+	updatedModel := newModel.(Model)
+	if !updatedModel.isLoading {
+		t.Error("Model should be loading while the action is in flight")
+	}
+	if len(updatedModel.gameState.History) != 0 {
+		t.Error("Shared game state should not change until the action completes")
+	}
+
+	// Streamed chunks are shown after the pending action
+	chunk := narrationChunkMsg{id: updatedModel.requestID, text: "The mist parts"}
+	newModel, _ = updatedModel.Update(chunk)
+	view := newModel.(Model).View()
+	if !strings.Contains(view, "> look around") || !strings.Contains(view, "The mist parts") {
+		t.Errorf("View should show the pending action and streamed text, got:\n%s", view)
+	}
+
+	// Without a model to narrate the turn, suggestions start with the result
+	var sawResult bool
+	for _, msg := range collectMsgs(t, cmd) {
+		newModel, cmd = updatedModel.Update(msg)
+		updatedModel = newModel.(Model)
+		if _, ok := msg.(actionResultMsg); ok {
+			sawResult = true
+			break
+		}
+	}
+	if !sawResult || cmd == nil {
+		t.Fatal("Expected the action result to start suggestions")
+	}
+	for _, msg := range collectMsgs(t, cmd) {
+		newModel, _ = updatedModel.Update(msg)
+		updatedModel = newModel.(Model)
+	}
+
+	if updatedModel.isLoading {
+		t.Error("Model should stop loading once the result arrives")
+	}
+	if updatedModel.streamText != "" || updatedModel.pendingAction != "" {
+		t.Error("Streaming state should be cleared after the result")
+	}
+	if updatedModel.gameState.Turn != 1 {
+		t.Errorf("Expected turn 1 after action, got %d", updatedModel.gameState.Turn)
+	}
+	if updatedModel.gameState.History[0].Content != "look around" {
+		t.Errorf("Expected player action in history, got %+v", updatedModel.gameState.History[0])
+	}
+	if len(updatedModel.suggestions) == 0 {
+		t.Error("Suggestions should be set once generated")
+	}
+}
+
+// collectMsgs runs a command and everything it batches or chains through an
+// action channel, returning the messages produced, except spinner ticks
+func collectMsgs(t *testing.T, cmd tea.Cmd) []tea.Msg {
+	t.Helper()
+	var msgs []tea.Msg
+	queue := []tea.Cmd{cmd}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if next == nil {
+			continue
+		}
+		switch msg := next().(type) {
+		case tea.BatchMsg:
+			queue = append(queue, msg...)
+		case spinnerTickMsg, nil:
+		case narrationChunkMsg:
+			msgs = append(msgs, msg)
+			queue = append(queue, waitForActionMsg(msg.ch))
+		default:
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+func TestModelWorldSetupRunsInBackground(t *testing.T) {
+	cfg := &config.Config{}
+	model := NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModeWorldSetup
+	model.inputValue = "A medieval fantasy kingdom"
+
+	newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updatedModel := newModel.(Model)
+	if !updatedModel.isLoading || updatedModel.mode != ModeWorldSetup {
+		t.Fatal("World setup should stay on the setup screen while loading")
+	}
+
+	result := awaitMsg(t, cmd, func(msg tea.Msg) bool {
+		_, ok := msg.(worldResultMsg)
+		return ok
+	})
+	newModel, _ = updatedModel.Update(result)
+	updatedModel = newModel.(Model)
+
+	if updatedModel.mode != ModePlaying {
+		t.Errorf("Expected playing mode after world creation, got %v", updatedModel.mode)
+	}
+	if updatedModel.gameState.World.Name == "" {
+		t.Error("World should be populated from the result")
+	}
+}
+
+func TestModelCancelRequest(t *testing.T) {
+	cfg := &config.Config{}
+	model := NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.inputValue = "open the gate"
+
+	newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	newModel, _ = newModel.(Model).Update(tea.KeyMsg{Type: tea.KeyEsc})
+	cancelled := newModel.(Model)
+
+	if cancelled.isLoading {
+		t.Error("Esc should stop loading")
+	}
+	if cancelled.inputValue != "open the gate" {
+		t.Errorf("Cancelled action should be restored to the input, got %q", cancelled.inputValue)
+	}
+
+	// The late result is ignored
+	result := awaitMsg(t, cmd, func(msg tea.Msg) bool {
+		_, ok := msg.(actionResultMsg)
+		return ok
+	})
+	newModel, _ = cancelled.Update(result)
+	if newModel.(Model).gameState.Turn != 0 {
+		t.Error("Result of a cancelled action should not be applied")
+	}
+}
+
+func TestModelQuitCancelsRequests(t *testing.T) {
+	cfg := &config.Config{}
+	model := NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.inputValue = "look around"
+
+	newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	loading := newModel.(Model)
+	requestCtx := loading.ctx
+
+	loading.mode = ModeMainMenu
+	_, cmd := loading.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil {
+		t.Fatal("Quit should return tea.Quit command")
+	}
+
+	if requestCtx.Err() == nil {
+		t.Error("Quitting should cancel outstanding engine requests")
+	}
+}
+
+func TestModelMapPanel(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 90, Height: 24}}
+	model := *NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.gameState.World = mapTestWorld()
+	model.gameState.AddHistoryEntry(entryTypeNarrator, "You stand at the gate.")
+
+	if strings.Contains(model.View(), "MAP") {
+		t.Error("The map panel should be hidden by default")
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	model = updated.(Model)
+	view := model.View()
+	if !strings.Contains(view, " | MAP") || !strings.Contains(view, "[*Gate]") {
+		t.Errorf("Expected the map beside the history, got:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if len(line) > model.width {
+			t.Errorf("Line wider than the terminal: %q", line)
+		}
+	}
+
+	// Narrow terminals have no room for the panel
+	model.width = 50
+	if strings.Contains(model.View(), "MAP") {
+		t.Error("The map panel should be hidden on narrow terminals")
+	}
+}
+
+func TestModelKeepsPreformattedLines(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 20, Height: 24}}
+	model := *NewModel(cfg, createTestTerminalInfo())
+	model.gameState.addPreformattedEntry(entryTypeSystem, "[Gate]--[Courtyard]--[Stables]")
+	model.gameState.AddHistoryEntry(entryTypeSystem, "A line of narration too long to fit")
+
+	lines := model.formatHistoryEntry(model.gameState.History[0])
+	if len(lines) != 1 || lines[0] != "[System] [Gate]--[" {
+		t.Errorf("Expected the map line cut at the panel edge, got %q", lines)
+	}
+	if lines := model.formatHistoryEntry(model.gameState.History[1]); len(lines) < 2 {
+		t.Errorf("Expected ordinary lines to wrap, got %q", lines)
+	}
+}
+
+func TestModelActionNeedsStateCopy(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 80, Height: 24}}
+	model := NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.gameState.Usage.Record("storytelling", TaskUsage{Cost: math.NaN()})
+	model.inputValue = "look around"
+
+	newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated := newModel.(Model)
+	if cmd != nil || updated.isLoading {
+		t.Fatal("An action should not start without a copy of the game state")
+	}
+	if updated.errorMessage == "" || updated.inputValue != "look around" {
+		t.Errorf("Expected an error and the action kept for retry, got %q / %q",
+			updated.errorMessage, updated.inputValue)
+	}
+}
+
+func TestModelKeepsUsageReportedDuringAction(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 80, Height: 24}}
+	model := *NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.isLoading = true
+	model.requestID = 2
+
+	// Suggestions for the previous turn finish while the action is in flight
+	var suggestionUsage UsageStats
+	suggestionUsage.Record("rule_setting", TaskUsage{Requests: 1, PromptTokens: 40})
+	updated, _ := model.Update(suggestionsMsg{id: 1, usage: suggestionUsage})
+	model = updated.(Model)
+
+	result := NewGameState()
+	result.Usage.Record("storytelling", TaskUsage{Requests: 1, PromptTokens: 100})
+	updated, _ = model.Update(actionResultMsg{id: 2, state: result})
+	model = updated.(Model)
+
+	usage := model.gameState.Usage
+	if usage.Tasks["rule_setting"].PromptTokens != 40 || usage.Tasks["storytelling"].PromptTokens != 100 {
+		t.Errorf("Expected suggestion and action usage after the result, got %+v", usage.Tasks)
+	}
+}
+
+func TestModelAppliesMemoryAfterAction(t *testing.T) {
+	cfg := &config.Config{
+		Terminal: config.TerminalConfig{Width: 80, Height: 24},
+		Game:     config.GameConfig{HistoryLimit: 20},
+	}
+	model := *NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	fillHistory(model.gameState, summarizeThreshold)
+	model.isLoading = true
+	model.requestID = 1
+	model.summarizing = true
+
+	// A summary of the live game arrives while an action is in flight
+	summary := memoryMsg{
+		game:   model.gameState.CreatedAt,
+		memory: Memory{Summary: "The ferry left without Mara.", SummarizedThrough: 20},
+	}
+	updated, _ := model.Update(summary)
+	model = updated.(Model)
+	if model.gameState.Memory.Summary != "" {
+		t.Fatal("Memory should wait for the action result")
+	}
+
+	result, err := model.gameState.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.AddHistoryEntry(entryTypePlayer, "wait")
+	updated, _ = model.Update(actionResultMsg{id: 1, state: result})
+	model = updated.(Model)
+
+	memory := model.gameState.Memory
+	if memory.Summary != "The ferry left without Mara." || model.summarizing {
+		t.Errorf("Expected the held summary to be applied, got %+v", memory)
+	}
+	if len(model.gameState.History) != 18 || memory.SummarizedThrough != 7 {
+		t.Errorf("Expected history archived after the summary, got %d entries summarized through %d",
+			len(model.gameState.History), memory.SummarizedThrough)
+	}
+
+	// Summaries of another game are dropped
+	model.summarizing = true
+	updated, _ = model.Update(memoryMsg{game: time.Now().Add(time.Hour), memory: Memory{Summary: "Elsewhere."}})
+	model = updated.(Model)
+	if model.gameState.Memory.Summary == "Elsewhere." || model.summarizing {
+		t.Errorf("Expected the other game's summary to be dropped, got %+v", model.gameState.Memory)
+	}
+}
+
+func TestModelKeepsUsageOfCancelledAction(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 80, Height: 24}}
+	model := *NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.isLoading = true
+	model.requestID = 1
+	model.pendingAction = "look around"
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = updated.(Model)
+
+	// The cancelled action's result still reports what its narration cost
+	var spent UsageStats
+	spent.Record("storytelling", TaskUsage{Requests: 1, PromptTokens: 100})
+	updated, _ = model.Update(actionResultMsg{id: 1, state: NewGameState(), spent: spent})
+	model = updated.(Model)
+
+	if got := model.gameState.Usage.Tasks["storytelling"]; got.PromptTokens != 100 {
+		t.Errorf("Expected the cancelled action's usage to be kept, got %+v", got)
+	}
+	if model.gameState.Turn != 0 {
+		t.Error("The cancelled action's turn should still be dropped")
+	}
+}
+
+func TestModelBuiltinCommandSkipsFollowUps(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 80, Height: 24}}
+	model := *NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.suggestions = []string{"Open the gate"}
+	model.inputValue = "inv"
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if len(model.suggestions) != 1 {
+		t.Errorf("A built-in command should keep the turn's suggestions, got %q", model.suggestions)
+	}
+
+	result := awaitMsg(t, cmd, func(msg tea.Msg) bool {
+		_, ok := msg.(actionResultMsg)
+		return ok
+	})
+	if !result.(actionResultMsg).builtin {
+		t.Fatal("Expected the result to be marked as a built-in command")
+	}
+	updated, cmd = model.Update(result)
+	model = updated.(Model)
+	if cmd != nil {
+		t.Error("A built-in command should not start suggestions or memory upkeep")
+	}
+	if model.gameState.Turn != 0 || !strings.Contains(model.gameState.History[1].Content, "inventory is empty") {
+		t.Errorf("Expected the inventory without a new turn, got turn %d and %+v", model.gameState.Turn, model.gameState.History)
+	}
+}
+
+func TestModelUsageIncludesHeldUsage(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 120, Height: 24}}
+	model := *NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.gameState.Usage.Record("storytelling", TaskUsage{Requests: 1, PromptTokens: 100})
+	model.heldUsage.Record("rule_setting", TaskUsage{Requests: 1, PromptTokens: 40})
+	model.inputValue = "usage"
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if cmd != nil || model.isLoading {
+		t.Error("The usage report should not need a request")
+	}
+
+	report := model.gameState.History[len(model.gameState.History)-1].Content
+	for _, want := range []string{"rule_setting: 1 requests, 40 prompt", "total: 2 requests, 140 prompt"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected usage report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestModelSuggestsBeforeActionResult(t *testing.T) {
+	engine, provider := newReplyEngine(t, "The mist parts.\n"+stateMarker+"\n{}", "Search the mist\nCall out")
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 80, Height: 24}}
+	model := *NewModel(cfg, createTestTerminalInfo())
+	model.engine = engine
+	model.mode = ModePlaying
+	model.inputValue = "look around"
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+
+	// The narration is ready while the action is still in flight
+	ready := awaitMsg(t, cmd, func(msg tea.Msg) bool {
+		_, ok := msg.(turnReadyMsg)
+		return ok
+	})
+	updated, cmd = model.Update(ready)
+	model = updated.(Model)
+	if !model.isLoading || cmd == nil {
+		t.Fatal("Expected suggestions to start before the action result")
+	}
+
+	var sawResult bool
+	for _, msg := range collectMsgs(t, cmd) {
+		var followUp tea.Cmd
+		updated, followUp = model.Update(msg)
+		model = updated.(Model)
+		if _, ok := msg.(actionResultMsg); ok {
+			sawResult = true
+			if followUp != nil {
+				t.Error("The action result should not start suggestions again")
+			}
+		}
+	}
+
+	if !sawResult || len(provider.requests) != 2 || provider.requests[1].Task != "rule_setting" {
+		t.Fatalf("Expected the action and one suggestion request, got %d requests", len(provider.requests))
+	}
+	if strings.Join(model.suggestions, "|") != "Search the mist|Call out" {
+		t.Errorf("Expected the early suggestions to be shown, got %q", model.suggestions)
+	}
+}
+
+func TestModelMapCommandUsesWindowWidth(t *testing.T) {
+	cfg := &config.Config{Terminal: config.TerminalConfig{Width: 200, Height: 24}}
+	model := *NewModel(cfg, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.gameState.World = mapTestWorld()
+
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 14, Height: 24})
+	model = updated.(Model)
+	model.inputValue = "map"
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+
+	result := awaitMsg(t, cmd, func(msg tea.Msg) bool {
+		_, ok := msg.(actionResultMsg)
+		return ok
+	})
+	history := result.(actionResultMsg).state.History
+	lines := strings.Split(history[len(history)-1].Content, "\n")[1:]
+	if len(lines) == 0 || !strings.Contains(strings.Join(lines, "\n"), "[*Gate]") {
+		t.Fatalf("Expected a map around the player, got %q", lines)
+	}
+	for _, line := range lines {
+		if len(line) > 12 {
+			t.Errorf("Map line wider than the resized window: %q", line)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// Dispositions run from hostile at -10 to devoted at 10
+	maxDisposition = 10
+	// Largest disposition change a single exchange can make
+	maxDispositionChange = 3
+	// Number of past exchanges a character remembers
+	maxNPCMemories = 20
+)
+
+// dialogOutcomePrompt asks the dialog model to report how an exchange went
+// alongside the usual state delta
+const dialogOutcomePrompt = `Also include these fields in the state JSON object:
+  "disposition_change": how the exchange changed the character's attitude to the player, from -3 (offended) to 3 (delighted), 0 if unchanged,
+  "npc_memory": "one sentence the character will remember about this exchange"`
+
+// NPC is a non-player character the player can meet and talk to
+type NPC struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Location is the name of the location where the character is
+	Location string `json:"location"`
+	// Disposition toward the player, from -10 (hostile) to 10 (devoted)
+	Disposition int `json:"disposition"`
+	// Memories are what the character remembers of past exchanges, oldest first
+	Memories []NPCMemory `json:"memories,omitempty"`
+}
+
+// NPCMemory is something a character remembers about talking to the player
+type NPCMemory struct {
+	Turn int    `json:"turn"`
+	Text string `json:"text"`
+}
+
+// npcSpec is a character introduced by a generated world or a state delta
+type npcSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Location    string `json:"location"`
+}
+
+// dialogOutcome is the part of a dialog reply's state delta about the character spoken to
+type dialogOutcome struct {
+	DispositionChange int    `json:"disposition_change"`
+	Memory            string `json:"npc_memory"`
+}
+
+// npcKey identifies a character regardless of how its name is capitalized
+func npcKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// NPC returns the named character, or nil if the player has not met them
+func (gs *GameState) NPC(name string) *NPC {
+	return gs.NPCs[npcKey(name)]
+}
+
+// meetNPC adds a character or updates one already known, returning the
+// character and whether it is new. An empty location leaves a known
+// character where they are and puts a new one at the player's location.
+func (gs *GameState) meetNPC(spec npcSpec) (*NPC, bool) {
+	if gs.NPCs == nil {
+		gs.NPCs = make(map[string]*NPC)
+	}
+	if npc := gs.NPC(spec.Name); npc != nil {
+		if npc.Description == "" {
+			npc.Description = spec.Description
+		}
+		if spec.Location != "" {
+			npc.Location = spec.Location
+		}
+		return npc, false
+	}
+
+	npc := &NPC{Name: spec.Name, Description: spec.Description, Location: spec.Location}
+	if npc.Location == "" {
+		npc.Location = gs.World.CurrentLocation
+	}
+	gs.NPCs[npcKey(spec.Name)] = npc
+	return npc, true
+}
+
+// npcsAt returns the characters at a location in name order
+func (gs *GameState) npcsAt(location string) []*NPC {
+	var present []*NPC
+	for _, npc := range gs.NPCs {
+		if strings.EqualFold(npc.Location, location) {
+			present = append(present, npc)
+		}
+	}
+	sort.Slice(present, func(i, j int) bool {
+		return npcKey(present[i].Name) < npcKey(present[j].Name)
+	})
+	return present
+}
+
+// npcAddressed returns the character a dialog command is aimed at: one at
+// the player's location that the input names, or else the only one there
+func (gs *GameState) npcAddressed(cmd Command) *NPC {
+	present := gs.npcsAt(gs.World.CurrentLocation)
+	words := strings.FieldsFunc(strings.ToLower(cmd.Raw), func(r rune) bool {
+		return !(r == '\'' || r == '-' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	})
+	for i := range words {
+		words[i] = strings.TrimSuffix(words[i], "'s")
+	}
+	for _, npc := range present {
+		if mentionsName(words, npc.Name) {
+			return npc
+		}
+	}
+	if len(present) == 1 {
+		return present[0]
+	}
+	return nil
+}
+
+// mentionsName reports whether input words include a name, either in full
+// or by its last word, so "Old Oskar" answers to "oskar" but not to "old"
+func mentionsName(words []string, name string) bool {
+	parts := strings.Fields(strings.ToLower(name))
+	if len(parts) == 0 {
+		return false
+	}
+	input := " " + strings.Join(words, " ") + " "
+	return strings.Contains(input, " "+strings.Join(parts, " ")+" ") ||
+		strings.Contains(input, " "+parts[len(parts)-1]+" ")
+}
+
+// dispositionLabel describes a disposition in words
+func dispositionLabel(disposition int) string {
+	switch {
+	case disposition <= -6:
+		return "hostile"
+	case disposition <= -2:
+		return "unfriendly"
+	case disposition < 2:
+		return "neutral"
+	case disposition < 6:
+		return "friendly"
+	default:
+		return "devoted"
+	}
+}
+
+// personaLines describe a character for the dialog model
+func (n *NPC) personaLines() []string {
+	lines := []string{
+		fmt.Sprintf("The player is speaking to %s: %s", n.Name, n.Description),
+		fmt.Sprintf("%s's attitude toward the player: %s (%d on a scale of -10 to 10)",
+			n.Name, dispositionLabel(n.Disposition), n.Disposition),
+	}
+	if len(n.Memories) == 0 {
+		return append(lines, fmt.Sprintf("%s has not spoken with the player before.", n.Name))
+	}
+	lines = append(lines, fmt.Sprintf("%s remembers from earlier conversations:", n.Name))
+	for _, memory := range n.Memories {
+		lines = append(lines, fmt.Sprintf("- (turn %d) %s", memory.Turn, memory.Text))
+	}
+	return lines
+}
+
+// recordExchange applies the outcome of a conversation to a character and
+// returns how much the disposition changed after clamping
+func (n *NPC) recordExchange(turn int, outcome dialogOutcome) int {
+	change := min(max(outcome.DispositionChange, -maxDispositionChange), maxDispositionChange)
+	before := n.Disposition
+	n.Disposition = min(max(n.Disposition+change, -maxDisposition), maxDisposition)
+
+	n.Memories = append(n.Memories, NPCMemory{Turn: turn, Text: outcome.Memory})
+	if len(n.Memories) > maxNPCMemories {
+		n.Memories = n.Memories[len(n.Memories)-maxNPCMemories:]
+	}
+	return n.Disposition - before
+}
+
+// applyDialogOutcome updates the character the player spoke to from the
+// dialog reply's state delta. The exchange is remembered even when the
+// reply carries no outcome, so the character never forgets being spoken to.
+func applyDialogOutcome(state *GameState, npc *NPC, action string, rawDelta []byte) {
+	var outcome dialogOutcome
+	if rawDelta != nil {
+		// A malformed outcome is treated as an exchange that changed nothing
+		_ = json.Unmarshal(rawDelta, &outcome)
+	}
+	outcome.Memory = strings.TrimSpace(outcome.Memory)
+	if outcome.Memory == "" {
+		outcome.Memory = "The player said: " + action
+	}
+
+	if change := npc.recordExchange(state.Turn, outcome); change != 0 {
+		state.AddHistoryEntry(entryTypeSystem, fmt.Sprintf("%s's attitude: %s (%+d)",
+			npc.Name, dispositionLabel(npc.Disposition), change))
+	}
+}
+
+// describeNPCs lists characters on one line for the game master
+func describeNPCs(npcs []*NPC) string {
+	descriptions := make([]string, 0, len(npcs))
+	for _, npc := range npcs {
+		text := fmt.Sprintf("%s (%s)", npc.Name, dispositionLabel(npc.Disposition))
+		if npc.Description != "" {
+			text += " - " + npc.Description
+		}
+		descriptions = append(descriptions, text)
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// normalizeNPCs drops unnamed characters and trims the fields of the rest
+func normalizeNPCs(npcs []npcSpec) []npcSpec {
+	normalized := make([]npcSpec, 0, len(npcs))
+	for _, npc := range npcs {
+		npc.Name = strings.TrimSpace(npc.Name)
+		npc.Description = strings.TrimSpace(npc.Description)
+		npc.Location = strings.TrimSpace(npc.Location)
+		if npc.Name != "" {
+			normalized = append(normalized, npc)
+		}
+	}
+	return normalized
+}
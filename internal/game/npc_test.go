@@ -0,0 +1,100 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNPCAddressed(t *testing.T) {
+	state := NewGameState()
+	state.World.moveTo(state.World.addLocation("Jetty", "Rotting planks."))
+	state.meetNPC(npcSpec{Name: "Old Oskar", Description: "A ferryman."})
+	state.meetNPC(npcSpec{Name: "Mara", Description: "A fisher."})
+	state.meetNPC(npcSpec{Name: "Abbot", Location: "Chapel"})
+
+	tests := map[string]string{
+		"talk to oskar":          "Old Oskar",
+		"ask Mara's advice":      "Mara",
+		"say hello":              "",
+		"talk to the abbot":      "",
+		"whisper to old friends": "",
+	}
+	for input, want := range tests {
+		got := ""
+		if npc := state.npcAddressed(ParseCommand(input)); npc != nil {
+			got = npc.Name
+		}
+		if got != want {
+			t.Errorf("%q: expected %q, got %q", input, want, got)
+		}
+	}
+
+	state.NPC("mara").Location = "Chapel"
+	if npc := state.npcAddressed(ParseCommand("say hello")); npc == nil || npc.Name != "Old Oskar" {
+		t.Errorf("Expected the only character present to be addressed, got %+v", npc)
+	}
+}
+
+func TestDialogUpdatesNPC(t *testing.T) {
+	engine, provider := newReplyEngine(t,
+		"Oskar grins.\n---STATE---\n{\"disposition_change\": 5, \"npc_memory\": \"The player asked after his boat.\"}",
+		"Oskar shrugs.",
+	)
+	state := NewGameState()
+	state.World.moveTo(state.World.addLocation("Jetty", "Rotting planks."))
+	state.meetNPC(npcSpec{Name: "Oskar", Description: "A ferryman."})
+	ctx := context.Background()
+
+	if err := engine.ProcessPlayerAction(ctx, state, "ask oskar about his boat"); err != nil {
+		t.Fatal(err)
+	}
+	oskar := state.NPC("Oskar")
+	if oskar.Disposition != maxDispositionChange {
+		t.Errorf("Expected the change to be clamped to %d, got %d", maxDispositionChange, oskar.Disposition)
+	}
+	if last := state.History[len(state.History)-1]; last.Content != "Oskar's attitude: friendly (+3)" {
+		t.Errorf("Expected the attitude change as a system entry, got %q", last.Content)
+	}
+	req := provider.requests[0]
+	if req.Task != "dialog" || !strings.Contains(strings.Join(req.Context, "\n"), "The player is speaking to Oskar: A ferryman.") {
+		t.Errorf("Expected a dialog request with Oskar's persona, got %s: %q", req.Task, req.Context)
+	}
+
+	// Replies without an outcome still leave a memory of the exchange
+	if err := engine.ProcessPlayerAction(ctx, state, "say goodbye"); err != nil {
+		t.Fatal(err)
+	}
+	if len(oskar.Memories) != 2 || oskar.Memories[1].Text != "The player said: say goodbye" || oskar.Disposition != 3 {
+		t.Errorf("Unexpected memories %+v / disposition %d", oskar.Memories, oskar.Disposition)
+	}
+	if !strings.Contains(strings.Join(provider.requests[1].Context, "\n"), "The player asked after his boat.") {
+		t.Error("Expected the first exchange to be remembered in the second prompt")
+	}
+}
+
+func TestStateDeltaIntroducesNPCs(t *testing.T) {
+	state := NewGameState()
+	state.World.moveTo(state.World.addLocation("Jetty", "Rotting planks."))
+	state.meetNPC(npcSpec{Name: "Oskar"})
+
+	delta, err := parseStateDelta([]byte(`{"npcs": [
+		{"name": "Mara", "description": "A fisher."},
+		{"name": "oskar", "description": "A ferryman.", "location": "Chapel"},
+		{"name": " "}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := applyStateDelta(state, delta)
+
+	if strings.Join(changes, "|") != "Met: Mara" {
+		t.Errorf("Expected only the new character to be announced, got %q", changes)
+	}
+	if mara := state.NPC("mara"); mara == nil || mara.Location != "Jetty" {
+		t.Errorf("Expected Mara at the player's location, got %+v", mara)
+	}
+	if oskar := state.NPC("Oskar"); oskar.Location != "Chapel" || oskar.Description != "A ferryman." {
+		t.Errorf("Expected Oskar to be updated, got %+v", oskar)
+	}
+}
@@ -0,0 +1,233 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Command is a player input broken into a verb and its objects, so that
+// "put the gem in the box" becomes verb "put", object "gem", preposition
+// "in" and indirect object "box"
+type Command struct {
+	// Raw is the input exactly as typed
+	Raw string
+	// Verb is the canonical lowercase verb with aliases resolved
+	Verb string
+	// Args is everything after the verb with its original case, for commands like save
+	Args        string
+	Object      string
+	Preposition string
+	Indirect    string
+}
+
+// verbAliases maps shorthand and synonyms to canonical verbs
+var verbAliases = map[string]string{
+	"l":       "look",
+	"x":       "examine",
+	"inspect": "examine",
+	"get":     "take",
+	"grab":    "take",
+	"speak":   "talk",
+	"walk":    "go",
+	"move":    "go",
+	"head":    "go",
+	"exit":    "quit",
+}
+
+// phrasalVerbs maps two-word verbs to canonical verbs
+var phrasalVerbs = map[string]string{
+	"pick up":  "take",
+	"look at":  "examine",
+	"talk to":  "talk",
+	"speak to": "talk",
+	"go to":    "go",
+	"walk to":  "go",
+}
+
+// directionAliases lets a bare direction stand for a movement command
+var directionAliases = map[string]string{
+	"n": "north", "s": "south", "e": "east", "w": "west", "u": "up", "d": "down",
+	"north": "north", "south": "south", "east": "east", "west": "west", "up": "up", "down": "down",
+	"ne": "northeast", "nw": "northwest", "se": "southeast", "sw": "southwest",
+	"northeast": "northeast", "northwest": "northwest", "southeast": "southeast", "southwest": "southwest",
+}
+
+// prepositions separate a direct object from an indirect one
+var prepositions = map[string]bool{
+	"with": true, "to": true, "at": true, "on": true, "onto": true, "in": true,
+	"into": true, "from": true, "using": true, "under": true, "behind": true,
+}
+
+// articles are dropped from objects
+var articles = map[string]bool{"the": true, "a": true, "an": true}
+
+// dialogVerbs are routed to the dialog model
+var dialogVerbs = map[string]bool{
+	"say": true, "talk": true, "tell": true, "ask": true, "shout": true, "whisper": true, "greet": true,
+}
+
+// ParseCommand tokenizes player input into a Command
+func ParseCommand(input string) Command {
+	cmd := Command{Raw: input}
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return cmd
+	}
+
+	verb := strings.ToLower(fields[0])
+	rest := fields[1:]
+
+	if len(rest) > 0 {
+		if phrasal, ok := phrasalVerbs[verb+" "+strings.ToLower(rest[0])]; ok {
+			verb = phrasal
+			rest = rest[1:]
+		}
+	}
+
+	if direction, ok := directionAliases[verb]; ok && len(rest) == 0 {
+		cmd.Verb = "go"
+		cmd.Object = direction
+		cmd.Args = direction
+		return cmd
+	}
+
+	cmd.Verb = canonicalVerb(verb)
+	cmd.Args = strings.Join(rest, " ")
+
+	var object, indirect []string
+	target := &object
+	for _, word := range rest {
+		lower := strings.ToLower(word)
+		if prepositions[lower] && cmd.Preposition == "" {
+			cmd.Preposition = lower
+			target = &indirect
+			continue
+		}
+		if articles[lower] {
+			continue
+		}
+		*target = append(*target, lower)
+	}
+	cmd.Object = strings.Join(object, " ")
+	cmd.Indirect = strings.Join(indirect, " ")
+
+	if cmd.Verb == "go" {
+		if direction, ok := directionAliases[cmd.Object]; ok {
+			cmd.Object = direction
+		}
+	}
+
+	return cmd
+}
+
+// canonicalVerb resolves built-in command aliases and verb synonyms
+func canonicalVerb(verb string) string {
+	for _, builtin := range builtinCommands {
+		if verb == builtin.Name {
+			return verb
+		}
+		for _, alias := range builtin.Aliases {
+			if verb == alias {
+				return builtin.Name
+			}
+		}
+	}
+	if canonical, ok := verbAliases[verb]; ok {
+		return canonical
+	}
+	return verb
+}
+
+// IsDialog reports whether the command is spoken rather than acted
+func (c Command) IsDialog() bool {
+	return dialogVerbs[c.Verb]
+}
+
+// builtinCommand is a command answered by the game itself instead of the AI
+type builtinCommand struct {
+	Name    string
+	Aliases []string
+	Usage   string
+	Help    string
+	// run executes the command in the engine, laying out its output for width
+	// columns; nil means the UI handles it
+	run func(e *Engine, state *GameState, cmd Command, width int)
+}
+
+// builtinCommands is the registry of built-in commands, in help order
+var builtinCommands []builtinCommand
+
+func init() {
+	builtinCommands = []builtinCommand{
+		{Name: "inventory", Aliases: []string{"inv", "i"}, Usage: "inventory", Help: "check your items", run: showInventory},
+		{Name: "stats", Usage: "stats", Help: "view character statistics", run: showStats},
+		{Name: "map", Usage: "map", Help: "show a map of the places you have visited", run: showMap},
+		{Name: "quests", Aliases: []string{"journal"}, Usage: "quests", Help: "review your quests and objectives", run: showQuests},
+		{Name: "usage", Usage: "usage", Help: "see AI tokens and cost spent on this game", run: showUsage},
+		{Name: "help", Aliases: []string{"?"}, Usage: "help", Help: "show this list", run: showHelp},
+		{Name: "save", Usage: "save [name]", Help: "save your game"},
+		{Name: "load", Usage: "load [name]", Help: "load a saved game"},
+		{Name: "quit", Usage: "quit", Help: "return to the main menu"},
+	}
+}
+
+// lookupBuiltin returns the built-in command for a canonical verb
+func lookupBuiltin(verb string) (builtinCommand, bool) {
+	for _, builtin := range builtinCommands {
+		if builtin.Name == verb {
+			return builtin, true
+		}
+	}
+	return builtinCommand{}, false
+}
+
+// isEngineCommand reports whether a command is a built-in the engine answers
+// itself. Engine built-ins take no arguments, so "help the old man" is still
+// an action for the game master.
+func isEngineCommand(cmd Command) bool {
+	builtin, ok := lookupBuiltin(cmd.Verb)
+	return ok && builtin.run != nil && cmd.Args == ""
+}
+
+func showInventory(e *Engine, state *GameState, cmd Command, width int) {
+	if len(state.Player.Inventory) == 0 {
+		state.AddHistoryEntry(entryTypeSystem, "Your inventory is empty.")
+		return
+	}
+	inventoryList := "Inventory:\n"
+	for _, item := range state.Player.Inventory {
+		inventoryList += fmt.Sprintf("- %s (x%d): %s\n", item.Name, item.Quantity, item.Description)
+	}
+	state.AddHistoryEntry(entryTypeSystem, inventoryList)
+}
+
+func showStats(e *Engine, state *GameState, cmd Command, width int) {
+	if len(state.Player.Stats) == 0 {
+		state.AddHistoryEntry(entryTypeSystem, "No stats to display.")
+		return
+	}
+	statsList := "Character Stats:\n"
+	for stat, value := range state.Player.Stats {
+		statsList += fmt.Sprintf("- %s: %d\n", stat, value)
+	}
+	state.AddHistoryEntry(entryTypeSystem, statsList)
+}
+
+func showMap(e *Engine, state *GameState, cmd Command, width int) {
+	// Match the two columns of padding the history panel leaves when wrapping
+	lines := renderMap(state.World, width-2, 0)
+	state.addPreformattedEntry(entryTypeSystem, "Map ([*] is you, [?] is unexplored):\n"+strings.Join(lines, "\n"))
+}
+
+func showHelp(e *Engine, state *GameState, cmd Command, width int) {
+	var help strings.Builder
+	help.WriteString("Available commands:\n- Type any action to interact with the world")
+	for _, builtin := range builtinCommands {
+		usage := "'" + builtin.Usage + "'"
+		for _, alias := range builtin.Aliases {
+			usage += " or '" + alias + "'"
+		}
+		help.WriteString(fmt.Sprintf("\n- %s to %s", usage, builtin.Help))
+	}
+	state.AddHistoryEntry(entryTypeSystem, help.String())
+}
@@ -0,0 +1,126 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"axon/internal/config"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Command
+	}{
+		{"i", Command{Verb: "inventory"}},
+		{"INV", Command{Verb: "inventory"}},
+		{"l", Command{Verb: "look"}},
+		{"n", Command{Verb: "go", Object: "north", Args: "north"}},
+		{"go to the market", Command{Verb: "go", Object: "market", Args: "the market"}},
+		{"walk e", Command{Verb: "go", Object: "east", Args: "e"}},
+		{"pick up the rusty sword", Command{Verb: "take", Object: "rusty sword", Args: "the rusty sword"}},
+		{"look at the mural", Command{Verb: "examine", Object: "mural", Args: "the mural"}},
+		{
+			"unlock the door with the brass key",
+			Command{Verb: "unlock", Object: "door", Preposition: "with", Indirect: "brass key", Args: "the door with the brass key"},
+		},
+		{"talk to the guard", Command{Verb: "talk", Object: "guard", Args: "the guard"}},
+		{"save My Adventure", Command{Verb: "save", Object: "my adventure", Args: "My Adventure"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got := ParseCommand(test.input)
+			test.want.Raw = test.input
+			if got != test.want {
+				t.Errorf("ParseCommand(%q) = %+v, want %+v", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseCommandNoSubstringMatches(t *testing.T) {
+	for _, input := range []string{"stay here", "write an essay", "forget the past", "slay the dragon"} {
+		cmd := ParseCommand(input)
+		if cmd.IsDialog() {
+			t.Errorf("%q should not be dialog", input)
+		}
+		if isEngineCommand(cmd) {
+			t.Errorf("%q should not be a built-in", input)
+		}
+	}
+
+	engine := NewEngine(&config.Config{})
+	if engine.isTakingAction(ParseCommand("forget the past").Verb) {
+		t.Error("forget should not be a taking action")
+	}
+	if engine.isMovementAction(ParseCommand("slay the dragon").Verb) {
+		t.Error("slay the dragon should not be a movement action")
+	}
+	if !ParseCommand("say hello").IsDialog() {
+		t.Error("say should be dialog")
+	}
+}
+
+func TestBuiltinCommandsNeedNoArguments(t *testing.T) {
+	if !isEngineCommand(ParseCommand("help")) {
+		t.Error("help should be a built-in")
+	}
+	if isEngineCommand(ParseCommand("help the old man")) {
+		t.Error("help with an object should go to the game master")
+	}
+}
+
+func TestProcessPlayerActionRoutesBuiltins(t *testing.T) {
+	engine, provider := newReplyEngine(t, "The narrator speaks.")
+	state := NewGameState()
+
+	for _, action := range []string{"help", "i", "stats"} {
+		if err := engine.ProcessPlayerAction(context.Background(), state, action); err != nil {
+			t.Fatalf("ProcessPlayerAction(%q) returned error: %v", action, err)
+		}
+		last := state.History[len(state.History)-1]
+		if last.Type != entryTypeSystem {
+			t.Errorf("%q should be answered by the game, got %+v", action, last)
+		}
+	}
+
+	if len(provider.requests) != 0 {
+		t.Errorf("Built-ins should not call the AI, got %d requests", len(provider.requests))
+	}
+
+	help := state.History[1].Content
+	for _, name := range []string{"inventory", "'i'", "save", "load", "quit"} {
+		if !strings.Contains(help, name) {
+			t.Errorf("Help should mention %s: %s", name, help)
+		}
+	}
+
+	if err := engine.ProcessPlayerAction(context.Background(), state, "talk to the innkeeper"); err != nil {
+		t.Fatalf("ProcessPlayerAction returned error: %v", err)
+	}
+	if len(provider.requests) != 1 || provider.requests[0].Task != "dialog" {
+		t.Errorf("Dialog should be sent to the dialog task, got %+v", provider.requests)
+	}
+}
+
+func TestModelQuitCommandAndTypedQ(t *testing.T) {
+	model := NewModel(&config.Config{}, createTestTerminalInfo())
+	model.mode = ModePlaying
+	model.gameState = NewGameState()
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	m := updated.(Model)
+	if m.mode != ModePlaying || m.inputValue != "q" {
+		t.Fatalf("Typing q during play should add it to the input, got mode %v input %q", m.mode, m.inputValue)
+	}
+
+	m.inputValue = "quit"
+	updated, _ = m.handleGameAction()
+	if updated.(Model).mode != ModeMainMenu {
+		t.Error("quit command should return to the main menu")
+	}
+}
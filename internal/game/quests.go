@@ -0,0 +1,235 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// Quest statuses
+	questActive    = "active"
+	questCompleted = "completed"
+	questFailed    = "failed"
+)
+
+// Quest is a goal the player is pursuing, opened and advanced by the game master
+type Quest struct {
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Objectives  []Objective `json:"objectives"`
+	// Status is "active", "completed" or "failed"
+	Status string `json:"status"`
+	// Rewards are given to the player when the quest is completed
+	Rewards []Item `json:"rewards,omitempty"`
+	// StartedTurn and EndedTurn are when the quest was opened and closed
+	StartedTurn int `json:"started_turn"`
+	EndedTurn   int `json:"ended_turn,omitempty"`
+}
+
+// Objective is one step of a quest
+type Objective struct {
+	Description string `json:"description"`
+	Done        bool   `json:"done"`
+}
+
+// questUpdate is a change to a quest reported in a state delta
+type questUpdate struct {
+	Title               string   `json:"title"`
+	Description         string   `json:"description"`
+	Objectives          []string `json:"objectives"`
+	CompletedObjectives []string `json:"completed_objectives"`
+	Status              string   `json:"status"`
+	Rewards             []Item   `json:"rewards"`
+}
+
+// quest returns the quest with a title, matched case-insensitively
+func (gs *GameState) quest(title string) *Quest {
+	for i := range gs.Quests {
+		if strings.EqualFold(gs.Quests[i].Title, title) {
+			return &gs.Quests[i]
+		}
+	}
+	return nil
+}
+
+// activeQuests returns the quests still being pursued, oldest first
+func (gs *GameState) activeQuests() []*Quest {
+	var active []*Quest
+	for i := range gs.Quests {
+		if gs.Quests[i].Status == questActive {
+			active = append(active, &gs.Quests[i])
+		}
+	}
+	return active
+}
+
+// objective returns the objective with a description, matched case-insensitively
+func (q *Quest) objective(description string) *Objective {
+	for i := range q.Objectives {
+		if strings.EqualFold(q.Objectives[i].Description, description) {
+			return &q.Objectives[i]
+		}
+	}
+	return nil
+}
+
+// openObjectives lists the objectives not yet done
+func (q *Quest) openObjectives() []string {
+	var open []string
+	for _, objective := range q.Objectives {
+		if !objective.Done {
+			open = append(open, objective.Description)
+		}
+	}
+	return open
+}
+
+// normalizeQuestUpdates drops untitled updates and unknown statuses, and
+// trims the fields of the rest
+func normalizeQuestUpdates(updates []questUpdate) []questUpdate {
+	normalized := make([]questUpdate, 0, len(updates))
+	for _, update := range updates {
+		update.Title = strings.TrimSpace(update.Title)
+		if update.Title == "" {
+			continue
+		}
+		update.Description = strings.TrimSpace(update.Description)
+		update.Objectives = trimmedNonEmpty(update.Objectives)
+		update.CompletedObjectives = trimmedNonEmpty(update.CompletedObjectives)
+		update.Status = strings.ToLower(strings.TrimSpace(update.Status))
+		switch update.Status {
+		case questActive, questCompleted, questFailed:
+		default:
+			update.Status = ""
+		}
+		update.Rewards = normalizeItems(update.Rewards)
+		normalized = append(normalized, update)
+	}
+	return normalized
+}
+
+// trimmedNonEmpty trims every string and drops the empty ones
+func trimmedNonEmpty(values []string) []string {
+	trimmed := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
+
+// applyQuestUpdate opens, advances or closes a quest and returns a
+// description of each change. Closed quests are not reopened, and rewards
+// are given once, when a quest is completed.
+func (gs *GameState) applyQuestUpdate(update questUpdate) []string {
+	var changes []string
+
+	quest := gs.quest(update.Title)
+	opened := quest == nil
+	if opened {
+		if update.Status == questCompleted || update.Status == questFailed {
+			// A quest the player never had cannot be finished
+			return nil
+		}
+		gs.Quests = append(gs.Quests, Quest{
+			Title:       update.Title,
+			Description: update.Description,
+			Status:      questActive,
+			StartedTurn: gs.Turn,
+		})
+		quest = &gs.Quests[len(gs.Quests)-1]
+		changes = append(changes, fmt.Sprintf("New quest: %s", quest.Title))
+	}
+	if quest.Status != questActive {
+		return changes
+	}
+
+	if len(update.Rewards) > 0 {
+		quest.Rewards = update.Rewards
+	}
+	for _, description := range update.Objectives {
+		if quest.objective(description) == nil {
+			quest.Objectives = append(quest.Objectives, Objective{Description: description})
+			if !opened {
+				changes = append(changes, fmt.Sprintf("New objective (%s): %s", quest.Title, description))
+			}
+		}
+	}
+	for _, description := range update.CompletedObjectives {
+		objective := quest.objective(description)
+		if objective == nil {
+			quest.Objectives = append(quest.Objectives, Objective{Description: description})
+			objective = &quest.Objectives[len(quest.Objectives)-1]
+		}
+		if !objective.Done {
+			objective.Done = true
+			changes = append(changes, fmt.Sprintf("Objective complete (%s): %s", quest.Title, objective.Description))
+		}
+	}
+
+	switch update.Status {
+	case questCompleted:
+		quest.Status = questCompleted
+		quest.EndedTurn = gs.Turn
+		changes = append(changes, fmt.Sprintf("Quest completed: %s", quest.Title))
+		for _, item := range quest.Rewards {
+			gs.Player.addItem(item)
+			changes = append(changes, fmt.Sprintf("Gained: %s (x%d)", item.Name, item.Quantity))
+		}
+	case questFailed:
+		quest.Status = questFailed
+		quest.EndedTurn = gs.Turn
+		changes = append(changes, fmt.Sprintf("Quest failed: %s", quest.Title))
+	}
+	return changes
+}
+
+// questPromptLines list the active quests and their open objectives for the game master
+func (gs *GameState) questPromptLines() []string {
+	active := gs.activeQuests()
+	if len(active) == 0 {
+		return nil
+	}
+	lines := []string{"Active quests:"}
+	for _, quest := range active {
+		line := "- " + quest.Title
+		if open := quest.openObjectives(); len(open) > 0 {
+			line += " - objectives: " + strings.Join(open, "; ")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func showQuests(e *Engine, state *GameState, cmd Command, width int) {
+	if len(state.Quests) == 0 {
+		state.AddHistoryEntry(entryTypeSystem, "You have no quests yet.")
+		return
+	}
+
+	var log strings.Builder
+	log.WriteString("Quests:")
+	for _, quest := range state.activeQuests() {
+		log.WriteString("\n" + quest.Title)
+		if quest.Description != "" {
+			log.WriteString(": " + quest.Description)
+		}
+		for _, objective := range quest.Objectives {
+			mark := "[ ]"
+			if objective.Done {
+				mark = "[x]"
+			}
+			log.WriteString(fmt.Sprintf("\n  %s %s", mark, objective.Description))
+		}
+		if len(quest.Rewards) > 0 {
+			log.WriteString("\n  Reward: " + describeInventory(quest.Rewards))
+		}
+	}
+	for _, quest := range state.Quests {
+		if quest.Status != questActive {
+			log.WriteString(fmt.Sprintf("\n%s (%s)", quest.Title, quest.Status))
+		}
+	}
+	state.AddHistoryEntry(entryTypeSystem, log.String())
+}
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// applyDelta parses and applies a state delta, failing the test if it is malformed
+func applyDelta(t *testing.T, state *GameState, raw string) []string {
+	t.Helper()
+	delta, err := parseStateDelta([]byte(raw))
+	if err != nil {
+		t.Fatalf("parseStateDelta returned error: %v", err)
+	}
+	return applyStateDelta(state, delta)
+}
+
+func TestQuestLifecycle(t *testing.T) {
+	state := NewGameState()
+
+	changes := applyDelta(t, state, `{"quests": [
+		{"title": "The Silent Bell", "description": "Find out why the bell stopped.",
+		 "objectives": ["Reach the chapel", "Find the bell rope"],
+		 "rewards": [{"name": "bell clapper", "quantity": 1}]},
+		{"title": "Never Started", "status": "completed"},
+		{"title": " "}
+	]}`)
+	if strings.Join(changes, "|") != "New quest: The Silent Bell" {
+		t.Errorf("Unexpected changes opening a quest: %q", changes)
+	}
+
+	changes = applyDelta(t, state, `{"quests": [{"title": "the silent bell",
+		"objectives": ["Ring the bell"], "completed_objectives": ["reach the chapel"]}]}`)
+	want := []string{
+		"New objective (The Silent Bell): Ring the bell",
+		"Objective complete (The Silent Bell): Reach the chapel",
+	}
+	if strings.Join(changes, "|") != strings.Join(want, "|") {
+		t.Errorf("Unexpected changes advancing a quest:\n got %q\nwant %q", changes, want)
+	}
+	if lines := state.questPromptLines(); len(lines) != 2 ||
+		lines[1] != "- The Silent Bell - objectives: Find the bell rope; Ring the bell" {
+		t.Errorf("Expected the open objectives for the game master, got %q", lines)
+	}
+	if setup := strings.Join(worldSetupLines(state), "\n"); !strings.Contains(setup, "Active quests:") {
+		t.Errorf("Expected active quests in the game-master context, got:\n%s", setup)
+	}
+
+	changes = applyDelta(t, state, `{"quests": [{"title": "The Silent Bell", "status": "Completed"}]}`)
+	want = []string{"Quest completed: The Silent Bell", "Gained: bell clapper (x1)"}
+	if strings.Join(changes, "|") != strings.Join(want, "|") {
+		t.Errorf("Unexpected changes completing a quest:\n got %q\nwant %q", changes, want)
+	}
+	if len(state.Player.Inventory) != 1 || state.questPromptLines() != nil {
+		t.Errorf("Expected the reward and no active quests, got %+v", state.Player.Inventory)
+	}
+
+	// Closed quests stay closed and pay out once
+	if changes := applyDelta(t, state, `{"quests": [{"title": "The Silent Bell", "status": "completed"}]}`); changes != nil {
+		t.Errorf("Expected no changes to a closed quest, got %q", changes)
+	}
+}
+
+func TestQuestsCommand(t *testing.T) {
+	engine, provider := newReplyEngine(t, "unused")
+	state := NewGameState()
+	ctx := context.Background()
+
+	if err := engine.ProcessPlayerAction(ctx, state, "quests"); err != nil {
+		t.Fatal(err)
+	}
+	if last := state.History[len(state.History)-1]; last.Content != "You have no quests yet." {
+		t.Errorf("Unexpected empty quest log %q", last.Content)
+	}
+
+	applyDelta(t, state, `{"quests": [
+		{"title": "Lost Sister", "description": "Find Ada.", "objectives": ["Ask at the jetty"]},
+		{"title": "Old Debt", "objectives": ["Pay Oskar"]}
+	]}`)
+	applyDelta(t, state, `{"quests": [{"title": "Old Debt", "status": "failed"}]}`)
+
+	if err := engine.ProcessPlayerAction(ctx, state, "journal"); err != nil {
+		t.Fatal(err)
+	}
+	want := "Quests:\nLost Sister: Find Ada.\n  [ ] Ask at the jetty\nOld Debt (failed)"
+	if last := state.History[len(state.History)-1]; last.Content != want {
+		t.Errorf("Unexpected quest log:\n%s\nwant:\n%s", last.Content, want)
+	}
+	if len(provider.requests) != 0 {
+		t.Error("The quests command should not call the AI")
+	}
+}
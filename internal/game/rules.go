@@ -0,0 +1,228 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"axon/internal/logger"
+)
+
+const (
+	// Sides of the die rolled for skill checks
+	checkDie = 20
+	// Difficulty used when the model names none, and the range difficulties are held to
+	defaultDifficulty = 12
+	minDifficulty     = 2
+	maxDifficulty     = 30
+)
+
+// checkSchemaPrompt describes the JSON object the rule_setting model must
+// return when judging whether an action needs a skill check
+const checkSchemaPrompt = `Decide whether the player's action needs a skill check. Only call for one when the outcome is uncertain and failure would be interesting.
+Respond with a single JSON object and nothing else, using this shape:
+{
+  "check": true,
+  "stat": "the player stat that applies, exactly as listed",
+  "difficulty": 12,
+  "reason": "a few words on what is being attempted"
+}
+A check succeeds when a d20 roll plus the stat meets the difficulty: 8 is easy, 12 moderate, 16 hard and 20 very hard.
+Respond with {"check": false} for actions that simply happen.`
+
+// skillCheck is the rule_setting model's ruling on a player action
+type skillCheck struct {
+	Check      bool   `json:"check"`
+	Stat       string `json:"stat"`
+	Difficulty int    `json:"difficulty"`
+	Reason     string `json:"reason"`
+}
+
+// checkResult is a rolled skill check
+type checkResult struct {
+	Stat       string
+	Value      int
+	Roll       int
+	Difficulty int
+	Reason     string
+}
+
+// dice rolls skill checks from a seedable source, so a fixed seed replays the same rolls
+type dice struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// newDice creates dice from a seed; zero seeds them from the clock
+func newDice(seed int64) *dice {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &dice{rng: rand.New(rand.NewSource(seed))}
+}
+
+// roll returns a number from 1 to sides
+func (d *dice) roll(sides int) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.rng.Intn(sides) + 1
+}
+
+// parseSkillCheck decodes and validates a ruling against the player's stats,
+// naming the stat as the player's stats spell it
+func parseSkillCheck(raw []byte, stats map[string]int) (*skillCheck, error) {
+	var check skillCheck
+	if err := json.Unmarshal(raw, &check); err != nil {
+		return nil, fmt.Errorf("malformed skill check JSON: %w", err)
+	}
+	if !check.Check {
+		return &check, nil
+	}
+
+	stat := strings.TrimSpace(check.Stat)
+	if stat == "" {
+		return nil, errors.New("skill check is missing a stat")
+	}
+	check.Stat = ""
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+		if strings.EqualFold(name, stat) {
+			check.Stat = name
+		}
+	}
+	if check.Stat == "" {
+		sort.Strings(names)
+		return nil, fmt.Errorf("stat %q is not one of the player's stats: %s", stat, strings.Join(names, ", "))
+	}
+
+	if check.Difficulty <= 0 {
+		check.Difficulty = defaultDifficulty
+	}
+	check.Difficulty = min(max(check.Difficulty, minDifficulty), maxDifficulty)
+	check.Reason = strings.TrimSpace(check.Reason)
+	return &check, nil
+}
+
+// Total is the roll plus the stat
+func (r checkResult) Total() int {
+	return r.Roll + r.Value
+}
+
+// Success reports whether the check passed. A natural 20 always succeeds
+// and a natural 1 always fails.
+func (r checkResult) Success() bool {
+	switch r.Roll {
+	case checkDie:
+		return true
+	case 1:
+		return false
+	}
+	return r.Total() >= r.Difficulty
+}
+
+// outcome describes the result in a few words
+func (r checkResult) outcome() string {
+	switch {
+	case r.Roll == checkDie:
+		return "critical success"
+	case r.Roll == 1:
+		return "critical failure"
+	case r.Success():
+		return "success"
+	default:
+		return "failure"
+	}
+}
+
+// String describes the check for the player
+func (r checkResult) String() string {
+	attempt := "Check"
+	if r.Reason != "" {
+		attempt += " to " + r.Reason
+	}
+	return fmt.Sprintf("%s (%s %d, difficulty %d): rolled %d + %d = %d, %s",
+		attempt, r.Stat, r.Value, r.Difficulty, r.Roll, r.Value, r.Total(), r.outcome())
+}
+
+// promptLine tells the game master how the check went
+func (r checkResult) promptLine() string {
+	verdict := "succeeds"
+	if !r.Success() {
+		verdict = "fails"
+	}
+	return fmt.Sprintf("The player made a %s check against difficulty %d and rolled %d + %d = %d, a %s. "+
+		"Narrate the action so that it %s; the dice have decided.",
+		r.Stat, r.Difficulty, r.Roll, r.Value, r.Total(), r.outcome(), verdict)
+}
+
+// resolveSkillCheck asks the rule_setting model whether an action needs a
+// skill check and rolls it if so, recording the result as a system entry.
+// It returns nil when no check was made, including when the ruling fails,
+// so the action is simply narrated. Dialog is never rolled for, so talking
+// costs no ruling.
+func (e *Engine) resolveSkillCheck(ctx context.Context, state *GameState, cmd Command) *checkResult {
+	if !e.config.Game.SkillChecks || len(state.Player.Stats) == 0 || cmd.IsDialog() {
+		return nil
+	}
+
+	promptContext := []string{
+		"You are the rules referee for a text-based adventure game.",
+		fmt.Sprintf("World: %s - %s", state.World.Name, state.World.Description),
+	}
+	if len(state.World.Rules) > 0 {
+		promptContext = append(promptContext, "World rules: "+strings.Join(state.World.Rules, "; "))
+	}
+	promptContext = append(promptContext,
+		fmt.Sprintf("Current Location: %s", state.World.CurrentLocation),
+		fmt.Sprintf("Player stats: %s", describeStats(state.Player.Stats)),
+	)
+	if state.Player.Status != "" {
+		promptContext = append(promptContext, fmt.Sprintf("Player status: %s", state.Player.Status))
+	}
+	for i := len(state.History) - 1; i >= 0; i-- {
+		if state.History[i].Type == entryTypeNarrator {
+			promptContext = append(promptContext, "Latest narration: "+state.History[i].Content)
+			break
+		}
+	}
+	promptContext = append(promptContext, checkSchemaPrompt)
+
+	req := e.taskRequest("rule_setting")
+	req.Prompt = "Player action: " + cmd.Raw
+	req.Context = promptContext
+
+	taskCtx, cancel := e.taskContext(ctx, "rule_setting")
+	defer cancel()
+
+	var check *skillCheck
+	err := e.generateJSON(taskCtx, state, req, func(raw []byte) error {
+		parsed, err := parseSkillCheck(raw, state.Player.Stats)
+		check = parsed
+		return err
+	})
+	if err != nil {
+		logger.Error("Skill check ruling failed, narrating without one: %v", err)
+		return nil
+	}
+	if !check.Check {
+		return nil
+	}
+
+	result := &checkResult{
+		Stat:       check.Stat,
+		Value:      state.Player.Stats[check.Stat],
+		Roll:       e.dice.roll(checkDie),
+		Difficulty: check.Difficulty,
+		Reason:     check.Reason,
+	}
+	logger.Info("Skill check: %s", result)
+	state.AddHistoryEntry(entryTypeSystem, result.String())
+	return result
+}
@@ -0,0 +1,126 @@
+package game
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseSkillCheck(t *testing.T) {
+	stats := map[string]int{"Agility": 4, "wits": 2}
+
+	check, err := parseSkillCheck([]byte(`{"check": true, "stat": "agility", "difficulty": 99, "reason": "climb the wall"}`), stats)
+	if err != nil {
+		t.Fatalf("parseSkillCheck returned error: %v", err)
+	}
+	if check.Stat != "Agility" || check.Difficulty != maxDifficulty {
+		t.Errorf("Expected the player's stat name and a clamped difficulty, got %+v", check)
+	}
+
+	if check, err := parseSkillCheck([]byte(`{"check": false}`), stats); err != nil || check.Check {
+		t.Errorf("Expected no check, got %+v / %v", check, err)
+	}
+	if check, _ := parseSkillCheck([]byte(`{"check": true, "stat": "wits"}`), stats); check.Difficulty != defaultDifficulty {
+		t.Errorf("Expected the default difficulty, got %d", check.Difficulty)
+	}
+
+	_, err = parseSkillCheck([]byte(`{"check": true, "stat": "charm", "difficulty": 10}`), stats)
+	if err == nil || !strings.Contains(err.Error(), "Agility, wits") {
+		t.Errorf("Expected an error listing the player's stats, got %v", err)
+	}
+}
+
+func TestCheckResultOutcome(t *testing.T) {
+	tests := []struct {
+		roll, value, difficulty int
+		want                    string
+	}{
+		{10, 4, 14, "success"},
+		{9, 4, 14, "failure"},
+		{20, 0, 30, "critical success"},
+		{1, 20, 2, "critical failure"},
+	}
+	for _, test := range tests {
+		result := checkResult{Stat: "agility", Roll: test.roll, Value: test.value, Difficulty: test.difficulty}
+		if got := result.outcome(); got != test.want {
+			t.Errorf("Roll %d + %d against %d: expected %s, got %s", test.roll, test.value, test.difficulty, test.want, got)
+		}
+	}
+}
+
+func TestDiceAreSeedable(t *testing.T) {
+	a, b := newDice(42), newDice(42)
+	for i := 0; i < 20; i++ {
+		rollA, rollB := a.roll(checkDie), b.roll(checkDie)
+		if rollA != rollB {
+			t.Fatalf("Same seed should give the same rolls, got %d and %d", rollA, rollB)
+		}
+		if rollA < 1 || rollA > checkDie {
+			t.Fatalf("Roll %d out of range", rollA)
+		}
+	}
+}
+
+func TestSkillCheckFeedsNarration(t *testing.T) {
+	engine, provider := newReplyEngine(t,
+		`{"check": true, "stat": "agility", "difficulty": 15, "reason": "climb the wall"}`,
+		"You scramble up the wall.\n---STATE---\n{}",
+	)
+	engine.config.Game.SkillChecks = true
+	engine.dice = newDice(7)
+	roll := rand.New(rand.NewSource(7)).Intn(checkDie) + 1
+
+	state := NewGameState()
+	state.Player.Stats["agility"] = 4
+	if err := engine.ProcessPlayerAction(context.Background(), state, "climb the wall"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(provider.requests) != 2 || provider.requests[0].Task != "rule_setting" || !provider.requests[0].JSON {
+		t.Fatalf("Expected a rule_setting ruling before narration, got %d requests", len(provider.requests))
+	}
+	result := checkResult{Stat: "agility", Value: 4, Roll: roll, Difficulty: 15, Reason: "climb the wall"}
+	if entry := state.History[1]; entry.Type != entryTypeSystem || entry.Content != result.String() {
+		t.Errorf("Expected the roll as a system entry before the narration, got %+v", entry)
+	}
+	if !strings.Contains(strings.Join(provider.requests[1].Context, "\n"), result.promptLine()) {
+		t.Errorf("Expected the outcome in the narration prompt, got %q", provider.requests[1].Context)
+	}
+}
+
+func TestSkillChecksSkipped(t *testing.T) {
+	engine, provider := newReplyEngine(t, `{"check": false}`, "You wait.\n---STATE---\n{}")
+	engine.config.Game.SkillChecks = true
+	state := NewGameState()
+
+	// Without stats there is nothing to check against
+	if err := engine.ProcessPlayerAction(context.Background(), state, "wait"); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.requests) != 1 || provider.requests[0].Task != "storytelling" {
+		t.Errorf("Expected narration only, got %d requests", len(provider.requests))
+	}
+
+	// Dialog is narrated without a ruling
+	state.Player.Stats["wits"] = 2
+	provider.requests = nil
+	if err := engine.ProcessPlayerAction(context.Background(), state, "say hello"); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.requests) != 1 || provider.requests[0].Task != "dialog" {
+		t.Errorf("Expected dialog without a ruling, got %d requests", len(provider.requests))
+	}
+
+	// A ruling of no check adds no system entry
+	provider.requests = nil
+	provider.replies = []string{`{"check": false}`, "You wait.\n---STATE---\n{}"}
+	if err := engine.ProcessPlayerAction(context.Background(), state, "wait"); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range state.History {
+		if entry.Type == entryTypeSystem {
+			t.Errorf("Unexpected system entry %q", entry.Content)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package game
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -10,10 +12,20 @@ type GameState struct {
 	World *World `json:"world"`
 	// Player information
 	Player *Player `json:"player"`
+	// Characters the player has met, keyed by lowercase name
+	NPCs map[string]*NPC `json:"npcs"`
+	// Quest log, in the order quests were opened
+	Quests []Quest `json:"quests"`
 	// Game history
 	History []HistoryEntry `json:"history"`
+	// Archive holds the oldest history entries, moved out of History once it passes the history limit
+	Archive []HistoryEntry `json:"archive,omitempty"`
 	// Current turn
 	Turn int `json:"turn"`
+	// Long-term memory of older history
+	Memory Memory `json:"memory"`
+	// AI token usage and cost, per task
+	Usage UsageStats `json:"usage"`
 	// Game metadata
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -21,12 +33,13 @@ type GameState struct {
 
 // World represents the game world
 type World struct {
-	Name            string            `json:"name"`
-	Description     string            `json:"description"`
-	Setting         string            `json:"setting"`
-	Rules           []string          `json:"rules"`
-	Locations       map[string]string `json:"locations"`
-	CurrentLocation string            `json:"current_location"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Setting     string   `json:"setting"`
+	Rules       []string `json:"rules"`
+	// Locations is the location graph, keyed by location name
+	Locations       map[string]*Location `json:"locations"`
+	CurrentLocation string               `json:"current_location"`
 }
 
 // Player represents the player character
@@ -51,6 +64,9 @@ type HistoryEntry struct {
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
 	Turn      int       `json:"turn"`
+	// Preformatted entries, such as maps, are laid out by column and are cut
+	// at the panel edge rather than re-wrapped
+	Preformatted bool `json:"preformatted,omitempty"`
 }
 
 // NewGameState creates a new game state
@@ -58,14 +74,18 @@ func NewGameState() *GameState {
 	now := time.Now()
 	return &GameState{
 		World: &World{
-			Locations: make(map[string]string),
+			Locations: make(map[string]*Location),
 		},
 		Player: &Player{
 			Inventory: make([]Item, 0),
 			Stats:     make(map[string]int),
 		},
-		History:   make([]HistoryEntry, 0),
-		Turn:      0,
+		NPCs:    make(map[string]*NPC),
+		History: make([]HistoryEntry, 0),
+		Turn:    0,
+		Memory: Memory{
+			Facts: make(map[string]Fact),
+		},
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -83,6 +103,12 @@ func (gs *GameState) AddHistoryEntry(entryType, content string) {
 	gs.UpdatedAt = time.Now()
 }
 
+// addPreformattedEntry adds a history entry whose lines must keep their layout
+func (gs *GameState) addPreformattedEntry(entryType, content string) {
+	gs.AddHistoryEntry(entryType, content)
+	gs.History[len(gs.History)-1].Preformatted = true
+}
+
 // GetRecentHistory returns the last N history entries
 func (gs *GameState) GetRecentHistory(n int) []HistoryEntry {
 	if len(gs.History) <= n {
@@ -96,3 +122,41 @@ func (gs *GameState) NextTurn() {
 	gs.Turn++
 	gs.UpdatedAt = time.Now()
 }
+
+// Clone returns a deep copy of the game state, so it can be worked on
+// outside the UI goroutine without sharing maps or slices. The archive is
+// append-only, so it is shared rather than copied.
+func (gs *GameState) Clone() (*GameState, error) {
+	shallow := *gs
+	shallow.Archive = nil
+	data, err := json.Marshal(&shallow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy game state: %w", err)
+	}
+	clone := &GameState{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, fmt.Errorf("failed to copy game state: %w", err)
+	}
+	// Cap the capacity so appends to the clone's archive never write into the original's
+	clone.Archive = gs.Archive[:len(gs.Archive):len(gs.Archive)]
+	return clone, nil
+}
+
+// CompactHistory moves the oldest entries into the archive once History
+// grows past limit. It trims a tenth below the limit so compaction, and the
+// re-render it causes, happens every few dozen entries rather than every turn.
+// It returns the number of entries archived; a limit of zero or less disables it.
+func (gs *GameState) CompactHistory(limit int) int {
+	if limit <= 0 || len(gs.History) <= limit {
+		return 0
+	}
+
+	keep := max(limit-limit/10, 1)
+	evicted := len(gs.History) - keep
+	gs.Archive = append(gs.Archive, gs.History[:evicted]...)
+	gs.History = append([]HistoryEntry(nil), gs.History[evicted:]...)
+
+	// The summary still covers the archived entries; only the index moves
+	gs.Memory.SummarizedThrough = max(gs.Memory.SummarizedThrough-evicted, 0)
+	return evicted
+}
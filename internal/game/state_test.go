@@ -1,7 +1,10 @@
 package game
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"strings"
 	"testing"
 	"time"
 )
@@ -143,7 +146,7 @@ func TestWorldStruct(t *testing.T) {
 		Description:     "A test world",
 		Setting:         "Fantasy",
 		Rules:           []string{"rule1", "rule2"},
-		Locations:       map[string]string{"start": "Starting location"},
+		Locations:       map[string]*Location{"start": {Name: "start", Description: "Starting location"}},
 		CurrentLocation: "start",
 	}
 
@@ -231,3 +234,67 @@ func TestHistoryEntryStruct(t *testing.T) {
 		t.Error("Timestamp should match")
 	}
 }
+
+func TestCompactHistory(t *testing.T) {
+	state := NewGameState()
+	for i := 0; i < 25; i++ {
+		state.AddHistoryEntry("narrator", fmt.Sprintf("entry %d", i))
+	}
+	state.Memory.SummarizedThrough = 12
+
+	if archived := state.CompactHistory(30); archived != 0 {
+		t.Errorf("History under the limit should not be compacted, archived %d", archived)
+	}
+
+	archived := state.CompactHistory(20)
+	if archived != 7 || len(state.History) != 18 || len(state.Archive) != 7 {
+		t.Fatalf("Expected 7 archived and 18 kept, got %d archived, %d kept", len(state.Archive), len(state.History))
+	}
+	if state.Archive[0].Content != "entry 0" || state.History[0].Content != "entry 7" {
+		t.Errorf("Oldest entries should be archived first: %q / %q", state.Archive[0].Content, state.History[0].Content)
+	}
+	if state.Memory.SummarizedThrough != 5 {
+		t.Errorf("Summary index should follow the archived entries, got %d", state.Memory.SummarizedThrough)
+	}
+
+	if archived := state.CompactHistory(0); archived != 0 {
+		t.Error("A zero limit should disable compaction")
+	}
+}
+
+func TestCloneSharesArchiveSafely(t *testing.T) {
+	state := NewGameState()
+	state.Archive = make([]HistoryEntry, 1, 10)
+	state.Archive[0] = HistoryEntry{Type: "narrator", Content: "long ago"}
+
+	clone, err := state.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone.Archive = append(clone.Archive, HistoryEntry{Type: "narrator", Content: "later"})
+
+	if len(state.Archive) != 1 || state.Archive[:2][1].Content == "later" {
+		t.Error("Appending to a clone's archive must not touch the original")
+	}
+	if len(clone.Archive) != 2 || clone.Archive[0].Content != "long ago" {
+		t.Errorf("Clone should keep the archive, got %+v", clone.Archive)
+	}
+
+	data, err := json.Marshal(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"archive"`) {
+		t.Error("Archive should be part of the save")
+	}
+}
+
+func TestCloneReportsFailure(t *testing.T) {
+	state := NewGameState()
+	// JSON has no NaN, so the copy cannot be made
+	state.Usage.Record("storytelling", TaskUsage{Cost: math.NaN()})
+
+	if clone, err := state.Clone(); err == nil || clone != nil {
+		t.Errorf("Expected an error rather than a copy, got %p, %v", clone, err)
+	}
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"axon/internal/ai"
+	"axon/internal/logger"
+)
+
+// Number of times a malformed structured reply is sent back to the model for repair
+const structuredRepairAttempts = 1
+
+// errNoJSONObject is returned when a reply contains no JSON object at all
+var errNoJSONObject = errors.New("reply does not contain a JSON object")
+
+// extractJSONObject returns the outermost JSON object in a model reply,
+// tolerating markdown code fences and prose around it
+func extractJSONObject(text string) ([]byte, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, errNoJSONObject
+	}
+	return []byte(text[start : end+1]), nil
+}
+
+// generateJSON sends a request for a JSON reply and hands the object to
+// decode. When decode rejects the reply, the model is shown its reply and the
+// problem and asked again. An error is returned if the AI call fails or the
+// reply is still unusable after repair. Every attempt is recorded in the
+// game's usage.
+func (e *Engine) generateJSON(ctx context.Context, state *GameState, req ai.Request, decode func(raw []byte) error) error {
+	req.JSON = true
+	prompt := req.Prompt
+
+	for attempt := 0; ; attempt++ {
+		resp, err := e.aiClient.Generate(ctx, req)
+		e.recordUsage(state, req.Task, resp)
+		if err != nil {
+			return err
+		}
+
+		raw, err := extractJSONObject(resp.Text)
+		if err == nil {
+			err = decode(raw)
+		}
+		if err == nil {
+			return nil
+		}
+
+		logger.Error("Structured %s reply rejected (attempt %d): %v", req.Task, attempt+1, err)
+		if attempt >= structuredRepairAttempts || ctx.Err() != nil {
+			return fmt.Errorf("%w: invalid structured reply: %w", ai.ErrMalformed, err)
+		}
+
+		req.Prompt = fmt.Sprintf(
+			"%s\n\nYour previous reply could not be used: %v\nPrevious reply:\n%s\n\n"+
+				"Respond again with only the corrected JSON object.",
+			prompt, err, resp.Text,
+		)
+	}
+}
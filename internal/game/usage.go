@@ -0,0 +1,119 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"axon/internal/ai"
+)
+
+// UsageStats is the AI token usage and cost of a game, per task
+type UsageStats struct {
+	Tasks map[string]TaskUsage `json:"tasks,omitempty"`
+}
+
+// TaskUsage counts the requests, tokens and cost spent on one AI task
+type TaskUsage struct {
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// Cost is in US dollars, priced from the configured pricing table
+	Cost float64 `json:"cost"`
+}
+
+// add accumulates other into u
+func (u *TaskUsage) add(other TaskUsage) {
+	u.Requests += other.Requests
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.Cost += other.Cost
+}
+
+// Record adds one request's usage to a task
+func (s *UsageStats) Record(task string, usage TaskUsage) {
+	if s.Tasks == nil {
+		s.Tasks = make(map[string]TaskUsage)
+	}
+	total := s.Tasks[task]
+	total.add(usage)
+	s.Tasks[task] = total
+}
+
+// Merge adds all usage recorded in other, such as usage gathered on a clone
+func (s *UsageStats) Merge(other UsageStats) {
+	for task, usage := range other.Tasks {
+		s.Record(task, usage)
+	}
+}
+
+// since returns the usage recorded after before, an earlier copy of these stats
+func (s *UsageStats) since(before UsageStats) UsageStats {
+	var spent UsageStats
+	for task, usage := range s.Tasks {
+		prior := before.Tasks[task]
+		usage.Requests -= prior.Requests
+		usage.PromptTokens -= prior.PromptTokens
+		usage.CompletionTokens -= prior.CompletionTokens
+		usage.Cost -= prior.Cost
+		if usage.Requests > 0 {
+			spent.Record(task, usage)
+		}
+	}
+	return spent
+}
+
+// Total returns the usage summed over all tasks
+func (s *UsageStats) Total() TaskUsage {
+	var total TaskUsage
+	for _, usage := range s.Tasks {
+		total.add(usage)
+	}
+	return total
+}
+
+// recordUsage adds the tokens and cost of an AI response to the game's usage.
+// Requests are counted even when the provider reports no tokens; cached
+// responses cost nothing and are not counted.
+func (e *Engine) recordUsage(state *GameState, task string, resp *ai.Response) {
+	if resp == nil || resp.Cached {
+		return
+	}
+	state.Usage.Record(task, TaskUsage{
+		Requests:         1,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		Cost:             e.aiClient.Cost(resp.Model, resp.Usage),
+	})
+}
+
+// usageLines renders usage stats as a table, one line per task plus a total
+func usageLines(stats *UsageStats) []string {
+	if len(stats.Tasks) == 0 {
+		return []string{"No AI requests have been made in this game yet."}
+	}
+
+	tasks := make([]string, 0, len(stats.Tasks))
+	for task := range stats.Tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+
+	lines := []string{"AI usage for this game:"}
+	for _, task := range tasks {
+		lines = append(lines, formatTaskUsage(task, stats.Tasks[task]))
+	}
+	lines = append(lines, formatTaskUsage("total", stats.Total()))
+	return lines
+}
+
+// formatTaskUsage renders one line of the usage table
+func formatTaskUsage(label string, usage TaskUsage) string {
+	return fmt.Sprintf("- %s: %d requests, %d prompt + %d completion tokens, $%.4f",
+		label, usage.Requests, usage.PromptTokens, usage.CompletionTokens, usage.Cost)
+}
+
+// showUsage reports the tokens and cost spent on AI requests so far
+func showUsage(e *Engine, state *GameState, cmd Command, width int) {
+	state.AddHistoryEntry(entryTypeSystem, strings.Join(usageLines(&state.Usage), "\n"))
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"axon/internal/ai"
+)
+
+func TestUsageStatsRecordAndMerge(t *testing.T) {
+	var stats UsageStats
+	stats.Record("storytelling", TaskUsage{Requests: 1, PromptTokens: 100, CompletionTokens: 40, Cost: 0.01})
+	stats.Record("storytelling", TaskUsage{Requests: 1, PromptTokens: 50, CompletionTokens: 10, Cost: 0.005})
+
+	var suggestions UsageStats
+	suggestions.Record("rule_setting", TaskUsage{Requests: 1, PromptTokens: 20, CompletionTokens: 5})
+	stats.Merge(suggestions)
+
+	story := stats.Tasks["storytelling"]
+	if story.Requests != 2 || story.PromptTokens != 150 || story.CompletionTokens != 50 {
+		t.Errorf("Unexpected storytelling usage %+v", story)
+	}
+	total := stats.Total()
+	if total.Requests != 3 || total.PromptTokens != 170 || total.CompletionTokens != 55 {
+		t.Errorf("Unexpected total usage %+v", total)
+	}
+}
+
+func TestUsageStatsSince(t *testing.T) {
+	var stats UsageStats
+	stats.Record("storytelling", TaskUsage{Requests: 1, PromptTokens: 100})
+	stats.Record("dialog", TaskUsage{Requests: 1, PromptTokens: 80})
+	var before UsageStats
+	before.Merge(stats)
+
+	stats.Record("storytelling", TaskUsage{Requests: 1, PromptTokens: 60})
+	spent := stats.since(before)
+	if len(spent.Tasks) != 1 || spent.Tasks["storytelling"].PromptTokens != 60 {
+		t.Errorf("Expected only the new storytelling request, got %+v", spent.Tasks)
+	}
+}
+
+func TestEngineRecordsUsage(t *testing.T) {
+	engine, provider := newReplyEngine(t, "You walk north.", "Look around\nRest")
+	provider.usage = ai.Usage{PromptTokens: 300, CompletionTokens: 25}
+
+	state := NewGameState()
+	if err := engine.ProcessPlayerAction(context.Background(), state, "walk north"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.GenerateActionSuggestions(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+
+	story := state.Usage.Tasks["storytelling"]
+	if story.Requests != 1 || story.PromptTokens != 300 || story.CompletionTokens != 25 {
+		t.Errorf("Unexpected storytelling usage %+v", story)
+	}
+	if state.Usage.Tasks["rule_setting"].Requests != 1 {
+		t.Errorf("Expected suggestions to be recorded, got %+v", state.Usage.Tasks)
+	}
+
+	// Cached replies cost nothing and are not counted
+	engine.recordUsage(state, "storytelling", &ai.Response{Text: "cached", Cached: true})
+	if state.Usage.Tasks["storytelling"].Requests != 1 {
+		t.Errorf("Expected cached reply not to be counted, got %+v", state.Usage.Tasks["storytelling"])
+	}
+
+	// Usage survives a save round trip
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := &GameState{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Usage.Total() != state.Usage.Total() {
+		t.Errorf("Expected usage to be saved, got %+v", loaded.Usage)
+	}
+}
+
+func TestUsageCommand(t *testing.T) {
+	engine, provider := newReplyEngine(t, "unused")
+	state := NewGameState()
+	state.Usage.Record("storytelling", TaskUsage{Requests: 2, PromptTokens: 900, CompletionTokens: 120, Cost: 0.0125})
+
+	if err := engine.ProcessPlayerAction(context.Background(), state, "usage"); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.requests) != 0 {
+		t.Errorf("Expected usage to be answered without the AI, got %d requests", len(provider.requests))
+	}
+
+	report := state.History[len(state.History)-1].Content
+	for _, want := range []string{"storytelling: 2 requests, 900 prompt + 120 completion tokens, $0.0125", "total:"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected usage report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// worldSchemaPrompt describes the JSON object the world-building model must return
+const worldSchemaPrompt = `Respond with a single JSON object and nothing else, using this shape:
+{
+  "name": "name of the world",
+  "setting": "genre or setting in a few words",
+  "description": "2-3 immersive sentences introducing the world",
+  "rules": ["3-5 short rules or truths of this world"],
+  "locations": [{
+    "name": "location name",
+    "description": "one or two sentences",
+    "exits": [{"direction": "north", "to": "name of the location it leads to", "locked": false, "hidden": false}]
+  }],
+  "starting_location": "name of the location where the player begins",
+  "npcs": [{"name": "character name", "description": "one sentence about who they are", "location": "name of the location where they are"}],
+  "player": {
+    "name": "player character name",
+    "description": "one sentence about the player character",
+    "inventory": [{"name": "item", "description": "short description", "quantity": 1}],
+    "stats": {"health": 10}
+  }
+}
+Exit directions are north, south, east, west, northeast, northwest, southeast, southwest, up or down.
+An exit may lead to a location that is not listed; it will be described when the player first goes there.`
+
+// worldSpec is the structured world produced by the world-building model
+type worldSpec struct {
+	Name             string         `json:"name"`
+	Setting          string         `json:"setting"`
+	Description      string         `json:"description"`
+	Rules            []string       `json:"rules"`
+	Locations        []locationSpec `json:"locations"`
+	StartingLocation string         `json:"starting_location"`
+	NPCs             []npcSpec      `json:"npcs"`
+	Player           playerSpec     `json:"player"`
+}
+
+// locationSpec is a location in a generated world
+type locationSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Exits       []Exit `json:"exits"`
+}
+
+// playerSpec is the player character in a generated world
+type playerSpec struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Inventory   []Item         `json:"inventory"`
+	Stats       map[string]int `json:"stats"`
+}
+
+// parseWorldSpec decodes and validates a world JSON object, filling in
+// harmless omissions and rejecting replies that cannot describe a world
+func parseWorldSpec(raw []byte) (*worldSpec, error) {
+	var spec worldSpec
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("malformed world JSON: %w", err)
+	}
+
+	spec.Name = strings.TrimSpace(spec.Name)
+	spec.Description = strings.TrimSpace(spec.Description)
+	if spec.Name == "" {
+		return nil, errors.New("world is missing a name")
+	}
+	if spec.Description == "" {
+		return nil, errors.New("world is missing a description")
+	}
+
+	rules := make([]string, 0, len(spec.Rules))
+	for _, rule := range spec.Rules {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("world has no rules")
+	}
+	spec.Rules = rules
+
+	locations := make([]locationSpec, 0, len(spec.Locations))
+	for _, loc := range spec.Locations {
+		loc.Name = strings.TrimSpace(loc.Name)
+		if loc.Name != "" {
+			loc.Exits = normalizeExits(loc.Exits)
+			locations = append(locations, loc)
+		}
+	}
+	if len(locations) == 0 {
+		return nil, errors.New("world has no locations")
+	}
+	spec.Locations = locations
+
+	spec.StartingLocation = strings.TrimSpace(spec.StartingLocation)
+	if !spec.hasLocation(spec.StartingLocation) {
+		if spec.StartingLocation != "" {
+			return nil, fmt.Errorf("starting location %q is not one of the locations", spec.StartingLocation)
+		}
+		spec.StartingLocation = spec.Locations[0].Name
+	}
+
+	spec.NPCs = normalizeNPCs(spec.NPCs)
+	for i := range spec.NPCs {
+		if !spec.hasLocation(spec.NPCs[i].Location) {
+			spec.NPCs[i].Location = spec.StartingLocation
+		}
+	}
+
+	if spec.Setting == "" {
+		spec.Setting = "Adventure"
+	}
+	spec.Player.Inventory = normalizeItems(spec.Player.Inventory)
+
+	return &spec, nil
+}
+
+// hasLocation reports whether the spec defines a location with the given name
+func (spec *worldSpec) hasLocation(name string) bool {
+	for _, loc := range spec.Locations {
+		if loc.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeExits drops exits without a destination or a recognizable
+// direction, and puts directions in their canonical form
+func normalizeExits(exits []Exit) []Exit {
+	normalized := make([]Exit, 0, len(exits))
+	for _, exit := range exits {
+		exit.Direction = canonicalDirection(exit.Direction)
+		exit.To = strings.TrimSpace(exit.To)
+		if exit.Direction != "" && exit.To != "" {
+			normalized = append(normalized, exit)
+		}
+	}
+	return normalized
+}
+
+// normalizeItems drops unnamed items and gives every item a positive quantity
+func normalizeItems(items []Item) []Item {
+	normalized := make([]Item, 0, len(items))
+	for _, item := range items {
+		item.Name = strings.TrimSpace(item.Name)
+		if item.Name == "" {
+			continue
+		}
+		if item.Quantity < 1 {
+			item.Quantity = 1
+		}
+		normalized = append(normalized, item)
+	}
+	return normalized
+}
+
+// applyWorldSpec populates the game state from a generated world
+func applyWorldSpec(state *GameState, spec *worldSpec) {
+	state.World.Name = spec.Name
+	state.World.Description = spec.Description
+	state.World.Setting = spec.Setting
+	state.World.Rules = spec.Rules
+	for _, loc := range spec.Locations {
+		state.World.addLocation(loc.Name, loc.Description)
+	}
+	for _, loc := range spec.Locations {
+		from := state.World.Location(loc.Name)
+		for _, exit := range loc.Exits {
+			state.World.connect(from, exit)
+		}
+	}
+	start := state.World.Location(spec.StartingLocation)
+	state.World.moveTo(start)
+	for _, npc := range spec.NPCs {
+		state.meetNPC(npc)
+	}
+
+	if spec.Player.Name != "" {
+		state.Player.Name = spec.Player.Name
+	}
+	state.Player.Description = spec.Player.Description
+	state.Player.Inventory = spec.Player.Inventory
+	for stat, value := range spec.Player.Stats {
+		state.Player.Stats[stat] = value
+	}
+
+	state.AddHistoryEntry(entryTypeNarrator, spec.Description)
+	if start.Description != "" {
+		state.AddHistoryEntry(entryTypeNarrator, start.Description)
+	}
+}
+
+// applyThemedWorld populates the game state from a built-in themed world
+func applyThemedWorld(state *GameState, themeWorld *World) {
+	state.World.Name = themeWorld.Name
+	state.World.Description = themeWorld.Description
+	state.World.Setting = themeWorld.Setting
+	state.World.Rules = themeWorld.Rules
+	state.World.moveTo(state.World.addLocation(themeWorld.CurrentLocation, themeWorld.Description))
+	state.AddHistoryEntry(entryTypeNarrator, themeWorld.Description)
+	state.AddHistoryEntry(
+		entryTypeNarrator,
+		"The mists of reality shimmer and coalesce, drawing upon ancient memories and forgotten tales to weave this world into existence...",
+	)
+}
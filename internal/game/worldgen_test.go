@@ -0,0 +1,167 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const validWorldJSON = `{
+  "name": "The Drowned Isles",
+  "setting": "Nautical Fantasy",
+  "description": "A scattered archipelago where the tide hides sunken cities.",
+  "rules": ["The sea remembers", "Salt wards off spirits"],
+  "locations": [
+    {"name": "Harbor of Gulls", "description": "A crowded harbor of creaking piers.", "exits": [
+      {"direction": "E", "to": "Sunken Chapel"},
+      {"direction": "north", "to": "Lighthouse", "locked": true},
+      {"direction": "sideways", "to": "Nowhere"}
+    ]},
+    {"name": "Sunken Chapel", "description": "A chapel visible only at low tide."}
+  ],
+  "starting_location": "Harbor of Gulls",
+  "npcs": [
+    {"name": "Brother Ansel", "description": "A monk who tends the tide bells.", "location": "Sunken Chapel"},
+    {"name": "Pike", "description": "A harbor urchin.", "location": "Somewhere"}
+  ],
+  "player": {
+    "name": "Mara",
+    "description": "A disgraced navigator.",
+    "inventory": [{"name": "brass compass", "description": "Always points to the sea", "quantity": 0}],
+    "stats": {"health": 10, "wits": 7}
+  }
+}`
+
+func TestParseWorldSpec(t *testing.T) {
+	spec, err := parseWorldSpec([]byte(validWorldJSON))
+	if err != nil {
+		t.Fatalf("parseWorldSpec returned error: %v", err)
+	}
+
+	if spec.Name != "The Drowned Isles" {
+		t.Errorf("Unexpected name %q", spec.Name)
+	}
+	if len(spec.Locations) != 2 || spec.StartingLocation != "Harbor of Gulls" {
+		t.Errorf("Unexpected locations %+v / %s", spec.Locations, spec.StartingLocation)
+	}
+	if spec.Player.Inventory[0].Quantity != 1 {
+		t.Errorf("Item quantity should be normalized to 1, got %d", spec.Player.Inventory[0].Quantity)
+	}
+}
+
+func TestParseWorldSpecValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"malformed", `{"name": `, "malformed"},
+		{"no name", `{"description": "d", "rules": ["r"], "locations": [{"name": "a"}]}`, "name"},
+		{"no rules", `{"name": "n", "description": "d", "locations": [{"name": "a"}]}`, "rules"},
+		{"no locations", `{"name": "n", "description": "d", "rules": ["r"]}`, "locations"},
+		{
+			"unknown start",
+			`{"name": "n", "description": "d", "rules": ["r"], "locations": [{"name": "a"}], "starting_location": "b"}`,
+			"starting location",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseWorldSpec([]byte(test.json))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Expected error mentioning %q, got %v", test.want, err)
+			}
+		})
+	}
+}
+
+func TestExtractJSONObject(t *testing.T) {
+	raw, err := extractJSONObject("Here you go:\n```json\n{\"a\": {\"b\": 1}}\n```")
+	if err != nil {
+		t.Fatalf("extractJSONObject returned error: %v", err)
+	}
+	if string(raw) != `{"a": {"b": 1}}` {
+		t.Errorf("Unexpected object %s", raw)
+	}
+
+	if _, err := extractJSONObject("no json here"); err == nil {
+		t.Error("Expected error for reply without JSON")
+	}
+}
+
+func TestInitializeWorldStructured(t *testing.T) {
+	engine, provider := newReplyEngine(t, "```json\n"+validWorldJSON+"\n```")
+	state := NewGameState()
+
+	if err := engine.InitializeWorld(context.Background(), state, "sunken islands"); err != nil {
+		t.Fatalf("InitializeWorld returned error: %v", err)
+	}
+
+	if !provider.requests[0].JSON {
+		t.Error("World generation should request JSON output")
+	}
+	if state.World.Name != "The Drowned Isles" || state.World.Setting != "Nautical Fantasy" {
+		t.Errorf("World not populated from JSON: %+v", state.World)
+	}
+	if state.World.CurrentLocation != "Harbor of Gulls" || len(state.World.Locations) != 3 {
+		t.Errorf("Locations not populated: %+v", state.World.Locations)
+	}
+	harbor := state.World.Current()
+	if !harbor.Visited || len(harbor.Exits) != 2 || !harbor.exit("north").Locked {
+		t.Errorf("Starting location exits not populated: %+v", harbor)
+	}
+	if chapel := state.World.Location("Sunken Chapel"); chapel.exit("west") == nil || chapel.Visited {
+		t.Errorf("Expected an unvisited chapel with a way back west, got %+v", chapel)
+	}
+	if lighthouse := state.World.Location("Lighthouse"); lighthouse == nil || lighthouse.Description != "" {
+		t.Errorf("Expected the unlisted lighthouse as a placeholder, got %+v", lighthouse)
+	}
+	if ansel := state.NPC("brother ansel"); ansel == nil || ansel.Location != "Sunken Chapel" {
+		t.Errorf("Characters not populated: %+v", state.NPCs)
+	}
+	if pike := state.NPC("Pike"); pike == nil || pike.Location != "Harbor of Gulls" {
+		t.Errorf("Characters in unknown places should start with the player, got %+v", pike)
+	}
+	if state.Player.Name != "Mara" || state.Player.Stats["wits"] != 7 {
+		t.Errorf("Player not populated: %+v", state.Player)
+	}
+	if len(state.Player.Inventory) != 1 || state.Player.Inventory[0].Name != "brass compass" {
+		t.Errorf("Inventory not populated: %+v", state.Player.Inventory)
+	}
+}
+
+func TestInitializeWorldRepairsMalformedReply(t *testing.T) {
+	engine, provider := newReplyEngine(t, `{"name": "Half a world"`, validWorldJSON)
+	state := NewGameState()
+
+	if err := engine.InitializeWorld(context.Background(), state, "sunken islands"); err != nil {
+		t.Fatalf("InitializeWorld returned error: %v", err)
+	}
+
+	if len(provider.requests) != 2 {
+		t.Fatalf("Expected a repair request, got %d requests", len(provider.requests))
+	}
+	if !strings.Contains(provider.requests[1].Prompt, "could not be used") {
+		t.Error("Repair request should explain the problem to the model")
+	}
+	if state.World.Name != "The Drowned Isles" {
+		t.Errorf("Expected repaired world, got %q", state.World.Name)
+	}
+}
+
+func TestInitializeWorldFallsBackAfterFailedRepair(t *testing.T) {
+	engine, provider := newReplyEngine(t, "Once upon a time there was a kingdom of magic.")
+	state := NewGameState()
+
+	if err := engine.InitializeWorld(context.Background(), state, "a fantasy kingdom"); err != nil {
+		t.Fatalf("InitializeWorld returned error: %v", err)
+	}
+
+	if len(provider.requests) != 1+structuredRepairAttempts {
+		t.Errorf("Expected %d requests, got %d", 1+structuredRepairAttempts, len(provider.requests))
+	}
+	if state.World.Name != "Realm of Eldoria" {
+		t.Errorf("Expected themed fallback world, got %q", state.World.Name)
+	}
+}
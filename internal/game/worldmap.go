@@ -0,0 +1,235 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Longest location name shown on the map before it is cut short
+const mapLabelLength = 12
+
+// mapPoint is a position on the map grid, with y growing southwards
+type mapPoint struct {
+	x, y int
+}
+
+// compassOffsets are the directions drawn on the map. Diagonal and vertical
+// exits would need characters beyond '-' and '|', so they are listed instead.
+var compassOffsets = map[string]mapPoint{
+	"north": {0, -1},
+	"south": {0, 1},
+	"east":  {1, 0},
+	"west":  {-1, 0},
+}
+
+// directionOrder fixes the order exits are laid out in, so maps are stable
+var directionOrder = []string{
+	"north", "east", "south", "west", "northeast", "southeast", "southwest", "northwest", "up", "down",
+}
+
+// mapEdge is a drawn connection between two neighbouring grid positions
+type mapEdge struct {
+	from, to mapPoint
+}
+
+// worldMap is the explored part of the location graph laid out on a grid
+type worldMap struct {
+	labels map[mapPoint]string
+	edges  []mapEdge
+	// notes list the exits and visited locations the grid cannot show
+	notes []string
+}
+
+// layoutMap places the visited locations reachable from the current one on
+// a grid, walking visible exits outwards. Exits to places not yet visited are
+// drawn as "[?]". A location whose spot is already taken stays off the grid.
+func layoutMap(w *World) *worldMap {
+	current := w.Current()
+	if current == nil {
+		return nil
+	}
+
+	m := &worldMap{labels: make(map[mapPoint]string)}
+	placed := map[string]mapPoint{current.Name: {}}
+	m.labels[mapPoint{}] = mapLabel(current.Name, true)
+	queue := []*Location{current}
+	var offGrid []string
+
+	for len(queue) > 0 {
+		loc := queue[0]
+		queue = queue[1:]
+		at := placed[loc.Name]
+
+		for _, exit := range sortedExits(loc) {
+			dest := w.Location(exit.To)
+			if exit.Hidden || dest == nil {
+				continue
+			}
+			offset, drawable := compassOffsets[exit.Direction]
+			if !drawable {
+				offGrid = append(offGrid, fmt.Sprintf("%s: %s to %s", loc.Name, exit.Direction, visitedName(dest)))
+				continue
+			}
+
+			to := mapPoint{at.x + offset.x, at.y + offset.y}
+			if existing, ok := placed[dest.Name]; ok {
+				if existing == to {
+					m.addEdge(at, to)
+				}
+				continue
+			}
+			if _, taken := m.labels[to]; taken {
+				continue
+			}
+
+			placed[dest.Name] = to
+			m.addEdge(at, to)
+			if !dest.Visited {
+				m.labels[to] = "[?]"
+				continue
+			}
+			m.labels[to] = mapLabel(dest.Name, false)
+			queue = append(queue, dest)
+		}
+	}
+
+	var elsewhere []string
+	for _, name := range w.locationNames() {
+		if loc := w.Location(name); loc != nil && loc.Visited {
+			if _, ok := placed[loc.Name]; !ok {
+				elsewhere = append(elsewhere, loc.Name)
+			}
+		}
+	}
+	if len(elsewhere) > 0 {
+		offGrid = append(offGrid, "Also visited: "+strings.Join(elsewhere, ", "))
+	}
+	m.notes = offGrid
+	return m
+}
+
+// sortedExits returns a location's exits in map order
+func sortedExits(loc *Location) []Exit {
+	rank := make(map[string]int, len(directionOrder))
+	for i, direction := range directionOrder {
+		rank[direction] = i
+	}
+	exits := append([]Exit(nil), loc.Exits...)
+	sort.SliceStable(exits, func(i, j int) bool {
+		return rank[exits[i].Direction] < rank[exits[j].Direction]
+	})
+	return exits
+}
+
+// mapLabel is the grid cell text for a visited location
+func mapLabel(name string, current bool) string {
+	runes := []rune(name)
+	if len(runes) > mapLabelLength {
+		runes = runes[:mapLabelLength]
+	}
+	if current {
+		return "[*" + string(runes) + "]"
+	}
+	return "[" + string(runes) + "]"
+}
+
+// visitedName names a location on the map, hiding places not yet visited
+func visitedName(loc *Location) string {
+	if !loc.Visited {
+		return "?"
+	}
+	return loc.Name
+}
+
+// addEdge records a connection unless it is already drawn from the other end
+func (m *worldMap) addEdge(from, to mapPoint) {
+	for _, edge := range m.edges {
+		if (edge.from == from && edge.to == to) || (edge.from == to && edge.to == from) {
+			return
+		}
+	}
+	m.edges = append(m.edges, mapEdge{from, to})
+}
+
+// renderMap draws the explored world in plain ASCII, using only '-' and '|'
+// for connections so it displays on every terminal type. The grid is
+// cropped around the player's location to fit maxWidth and maxHeight; a
+// maxHeight of zero leaves the height unlimited. Exits the grid cannot show
+// follow the grid while there is room.
+func renderMap(w *World, maxWidth, maxHeight int) []string {
+	m := layoutMap(w)
+	if m == nil {
+		return []string{"No map yet."}
+	}
+
+	var minX, minY, maxX, maxY int
+	cellWidth := 0
+	for point, label := range m.labels {
+		minX, maxX = min(minX, point.x), max(maxX, point.x)
+		minY, maxY = min(minY, point.y), max(maxY, point.y)
+		cellWidth = max(cellWidth, len([]rune(label)))
+	}
+	stride := cellWidth + 2
+
+	rows := (maxY-minY)*2 + 1
+	cols := (maxX-minX)*stride + cellWidth
+	canvas := make([][]rune, rows)
+	for i := range canvas {
+		canvas[i] = []rune(strings.Repeat(" ", cols))
+	}
+	position := func(p mapPoint) (int, int) {
+		return (p.y - minY) * 2, (p.x - minX) * stride
+	}
+
+	for point, label := range m.labels {
+		row, col := position(point)
+		copy(canvas[row][col:], []rune(label))
+	}
+	for _, edge := range m.edges {
+		from, to := edge.from, edge.to
+		if to.x < from.x || to.y < from.y {
+			from, to = to, from
+		}
+		row, col := position(from)
+		if from.y == to.y {
+			_, end := position(to)
+			for c := col + len([]rune(m.labels[from])); c < end; c++ {
+				canvas[row][c] = '-'
+			}
+		} else {
+			canvas[row+1][col+1] = '|'
+		}
+	}
+
+	// Crop around the player, who is always at the origin
+	hereRow, hereCol := position(mapPoint{})
+	top, height := cropWindow(rows, maxHeight, hereRow)
+	left, width := cropWindow(cols, maxWidth, hereCol+cellWidth/2)
+
+	lines := make([]string, 0, height+len(m.notes))
+	for _, row := range canvas[top : top+height] {
+		lines = append(lines, strings.TrimRight(string(row[left:left+width]), " "))
+	}
+	for _, note := range m.notes {
+		if maxHeight > 0 && len(lines) >= maxHeight {
+			break
+		}
+		if runes := []rune(note); maxWidth > 0 && len(runes) > maxWidth {
+			note = string(runes[:maxWidth])
+		}
+		lines = append(lines, note)
+	}
+	return lines
+}
+
+// cropWindow returns the start and length of a window of at most limit
+// cells out of size, centred on focus where possible. A limit of zero or
+// less keeps everything.
+func cropWindow(size, limit, focus int) (int, int) {
+	if limit <= 0 || size <= limit {
+		return 0, size
+	}
+	start := min(max(focus-limit/2, 0), size-limit)
+	return start, limit
+}
@@ -0,0 +1,72 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// mapTestWorld is a gate with a visited courtyard to the north, a locked
+// vault to the east and a tower up a ladder
+func mapTestWorld() *World {
+	world := &World{}
+	gate := world.addLocation("Gate", "An iron gate.")
+	world.connect(gate, Exit{Direction: "north", To: "Courtyard"})
+	world.connect(gate, Exit{Direction: "east", To: "Vault", Locked: true})
+	world.connect(gate, Exit{Direction: "up", To: "Tower"})
+	world.connect(gate, Exit{Direction: "down", To: "Cellar", Hidden: true})
+	courtyard := world.Location("Courtyard")
+	world.connect(courtyard, Exit{Direction: "east", To: "Stables"})
+	world.moveTo(courtyard)
+	world.addLocation("Grotto", "A far-off grotto.").Visited = true
+	world.moveTo(gate)
+	return world
+}
+
+func TestRenderMap(t *testing.T) {
+	got := renderMap(mapTestWorld(), 0, 0)
+	want := []string{
+		"[Courtyard]--[?]",
+		" |",
+		"[*Gate]------[?]",
+		"Gate: up to ?",
+		"Also visited: Grotto",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected map:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, line := range got {
+		if strings.ContainsAny(line, "+/\\=") || strings.ContainsFunc(line, func(r rune) bool { return r > 127 }) {
+			t.Errorf("Map should only use plain ASCII '-' and '|' connectors, got %q", line)
+		}
+	}
+}
+
+func TestRenderMapCropsAroundPlayer(t *testing.T) {
+	got := renderMap(mapTestWorld(), 10, 2)
+	want := []string{" |", "[*Gate]---"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected the map cropped around the player, got %q", got)
+	}
+
+	if got := renderMap(&World{}, 10, 2); len(got) != 1 || got[0] != "No map yet." {
+		t.Errorf("Expected a placeholder without a current location, got %q", got)
+	}
+}
+
+func TestMapCommand(t *testing.T) {
+	engine, provider := newReplyEngine(t, "unused")
+	state := NewGameState()
+	state.World = mapTestWorld()
+
+	if err := engine.ProcessPlayerAction(context.Background(), state, "map"); err != nil {
+		t.Fatal(err)
+	}
+	last := state.History[len(state.History)-1]
+	if last.Type != entryTypeSystem || !strings.Contains(last.Content, "\n[*Gate]------[?]\n") {
+		t.Errorf("Expected the map as a system entry, got %q", last.Content)
+	}
+	if len(provider.requests) != 0 {
+		t.Error("The map command should not call the AI")
+	}
+}